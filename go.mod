@@ -3,9 +3,11 @@ module lunarfetch
 go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/disintegration/imaging v1.6.2
 	github.com/mattn/go-sixel v0.0.5
 	golang.org/x/image v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/soniakeys/quant v1.0.0 // indirect
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"lunarfetch/src/common"
 	"lunarfetch/src/scripts"
 	"lunarfetch/src/utils"
 )
@@ -31,33 +33,60 @@ type Logo = scripts.Logo
 
 type Info = scripts.Info
 
+type Options struct {
+	ConfigPath string
+	Format     string
+	Theme      string
+	// Timings is the --timings report format, empty when not requested
+	Timings string
+}
+
 func main() {
+	start := time.Now()
 
-	configPath, shouldExit := parseCommandLineArgs()
+	options, shouldExit := parseCommandLineArgs()
 	if shouldExit {
 		return
 	}
 
-	config := loadConfiguration(configPath)
+	if options.Format != "" && !utils.IsOutputFormat(options.Format) {
+		fmt.Fprintf(os.Stderr, "Unsupported output format: %s (expected json, yaml or toml)\n", options.Format)
+		os.Exit(1)
+	}
+
+	if options.Timings != "" && !utils.IsTimingsFormat(options.Timings) {
+		fmt.Fprintf(os.Stderr, "Unsupported timings format: %s (expected text or json)\n", options.Timings)
+		os.Exit(1)
+	}
 
-	runLunarFetch(config)
+	var timings *utils.Timings
+	if options.Timings != "" {
+		timings = utils.NewTimings(start)
+	}
+
+	configStart := time.Now()
+	config := loadConfiguration(options.ConfigPath)
+	config = applyTheme(config, options.Theme)
+	timings.RecordPhase("config", time.Since(configStart))
+
+	runLunarFetch(config, options, timings)
 }
 
-func parseCommandLineArgs() (string, bool) {
-	var configPath string
+func parseCommandLineArgs() (Options, bool) {
+	var options Options
 
 	if len(os.Args) <= 1 {
-		return "", false
+		return options, false
 	}
 
 	if os.Args[1] == "--help" || os.Args[1] == "-h" {
 		scripts.PrintUsage()
-		return "", true
+		return options, true
 	}
 
 	if os.Args[1] == "--version" || os.Args[1] == "-v" {
 		scripts.PrintVersion()
-		return "", true
+		return options, true
 	}
 
 	if os.Args[1] == "--debug" || os.Args[1] == "-d" {
@@ -72,27 +101,55 @@ func parseCommandLineArgs() (string, bool) {
 		}
 	}
 
+	options.ConfigPath = extractFlagValue("--config", "-c")
+	options.Format = extractFlagValue("--format", "-f")
+	options.Theme = extractFlagValue("--theme", "-t")
+	if format, found := extractOptionalFlag("--timings", utils.TimingsText); found {
+		options.Timings = format
+	}
+
+	if len(os.Args) > 1 {
+		scripts.HandleCommands(os.Args[1:], options.ConfigPath)
+		return options, true
+	}
+
+	return options, false
+}
+
+func extractFlagValue(names ...string) string {
 	for i := 1; i < len(os.Args); i++ {
-		if os.Args[i] == "--config" || os.Args[i] == "-c" {
-			if i+1 < len(os.Args) {
-				configPath = os.Args[i+1]
-
-				newArgs := append([]string{os.Args[0]}, os.Args[1:i]...)
-				if i+2 < len(os.Args) {
-					newArgs = append(newArgs, os.Args[i+2:]...)
-				}
-				os.Args = newArgs
-				break
+		for _, name := range names {
+			if strings.HasPrefix(os.Args[i], name+"=") {
+				value := strings.TrimPrefix(os.Args[i], name+"=")
+				os.Args = append(os.Args[:i], os.Args[i+1:]...)
+				return value
+			}
+
+			if os.Args[i] == name && i+1 < len(os.Args) {
+				value := os.Args[i+1]
+				os.Args = append(os.Args[:i], os.Args[i+2:]...)
+				return value
 			}
 		}
 	}
+	return ""
+}
 
-	if len(os.Args) > 1 {
-		scripts.HandleCommands(os.Args[1:])
-		return "", true
+// extractOptionalFlag removes a flag given as --name or --name=value and
+// returns its value, or fallback for the bare form
+func extractOptionalFlag(name, fallback string) (string, bool) {
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == name {
+			os.Args = append(os.Args[:i], os.Args[i+1:]...)
+			return fallback, true
+		}
+		if strings.HasPrefix(os.Args[i], name+"=") {
+			value := strings.TrimPrefix(os.Args[i], name+"=")
+			os.Args = append(os.Args[:i], os.Args[i+1:]...)
+			return value, true
+		}
 	}
-
-	return configPath, false
+	return "", false
 }
 
 func loadConfiguration(configPath string) utils.Config {
@@ -103,13 +160,13 @@ func loadConfiguration(configPath string) utils.Config {
 	if configPath != "" {
 		config, err = configLoader.LoadConfig(configPath)
 		if err != nil {
-			fmt.Println("Error loading config from", configPath, ":", err)
+			fmt.Fprintln(os.Stderr, "Error loading config from", configPath, ":", err)
 			config = utils.DefaultConfig()
 		}
 	} else {
 		config, err = configLoader.LoadConfig()
 		if err != nil {
-			fmt.Println("Error loading config:", err)
+			fmt.Fprintln(os.Stderr, "Error loading config:", err)
 			config = utils.DefaultConfig()
 		}
 	}
@@ -117,17 +174,83 @@ func loadConfiguration(configPath string) utils.Config {
 	return config
 }
 
-func runLunarFetch(config utils.Config) {
+// applyTheme applies --theme, or else the theme named in config.json. An
+// unknown --theme is fatal, like other bad flags; a bad config entry only
+// drops the theme.
+func applyTheme(config utils.Config, flagTheme string) utils.Config {
+	name := config.Theme
+	if flagTheme != "" {
+		name = flagTheme
+	}
+	if name == "" {
+		return config
+	}
+
+	themed, err := utils.ApplyTheme(config, name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading theme:", err)
+		if flagTheme != "" {
+			os.Exit(1)
+		}
+		return config
+	}
+	return themed
+}
+
+func runLunarFetch(config utils.Config, options Options, timings *utils.Timings) {
+	if os.Getenv("LUNARFETCH_DEBUG") == "1" {
+		common.DefaultExecutor.AddHook(logCommand)
+	}
+	if timings != nil {
+		common.DefaultExecutor.AddHook(timings.RecordCommand)
+		defer reportTimings(timings, options.Timings)
+	}
 
 	displayManager := utils.NewDisplayManager(config)
+	displayManager.Timings = timings
 	displayManager.InitializeComponents()
 
-	sysInfoOutput := displayManager.Display()
+	if options.Format != "" {
+		output, err := displayManager.Render(options.Format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering %s output: %v\n", options.Format, err)
+			os.Exit(1)
+		}
+		fmt.Print(output)
+		return
+	}
+
+	displayManager.GetInfoParallel()
 
+	logoStart := time.Now()
 	logoOutput := loadLogo(config)
-	imageOutput := loadImage(config)
+	if config.Logo.EnableLogo {
+		timings.RecordPhase("logo", time.Since(logoStart))
+	}
+	imageOutput := loadImage(config, timings)
+
+	displayOutput(displayManager, logoOutput, imageOutput)
+}
 
-	displayOutput(config, sysInfoOutput, logoOutput, imageOutput)
+// reportTimings prints the --timings report to stderr, after the output
+func reportTimings(timings *utils.Timings, format string) {
+	timings.Finish()
+	if err := timings.Write(os.Stderr, format); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing timings:", err)
+	}
+}
+
+func logCommand(event common.CommandEvent) {
+	command := strings.TrimSpace(event.Name + " " + strings.Join(event.Args, " "))
+	if event.Cached {
+		fmt.Fprintf(os.Stderr, "exec %s (cached)\n", command)
+		return
+	}
+	if event.ExitCode == -1 && event.Err != nil {
+		fmt.Fprintf(os.Stderr, "exec %s: %v\n", command, event.Err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "exec %s: exit %d in %s\n", command, event.ExitCode, event.Duration.Round(time.Microsecond))
 }
 
 func loadLogo(config utils.Config) string {
@@ -143,12 +266,13 @@ func loadLogo(config utils.Config) string {
 	return logoOutput
 }
 
-func loadImage(config utils.Config) string {
+func loadImage(config utils.Config, timings *utils.Timings) string {
 	if !config.Image.EnableImage {
 		return ""
 	}
 
 	imageLoader := utils.NewImageLoader(config)
+	imageLoader.Timings = timings
 
 	if os.Getenv("LUNARFETCH_DEBUG") == "1" {
 		printImageDebugInfo(config)
@@ -180,223 +304,13 @@ func printImageDebugInfo(config utils.Config) {
 	fmt.Printf("  Random: %v\n", config.Image.Random)
 }
 
-func addSimpleMargin(sysInfo string, spaces int) string {
-	margin := strings.Repeat(" ", spaces)
-	lines := strings.Split(sysInfo, "\n")
-	for i, line := range lines {
-		lines[i] = margin + line
-	}
-	return strings.Join(lines, "\n")
-}
-
-func displayOutput(config utils.Config, sysInfo, logoOutput, imageOutput string) {
+func displayOutput(displayManager *utils.DisplayManager, logoOutput, imageOutput string) {
+	config := displayManager.Config
 	if os.Getenv("LUNARFETCH_DEBUG") == "1" {
 		fmt.Printf("Logo enabled: %v, position: %s\n", config.Logo.EnableLogo, config.Logo.Position)
 		fmt.Printf("Image enabled: %v, position: %s\n", config.Image.EnableImage, config.Image.Position)
 	}
 
-	sysInfo = strings.TrimSpace(sysInfo)
-	logoOutput = strings.TrimSpace(logoOutput)
-	imageOutput = strings.TrimSpace(imageOutput)
-
-	var topContent, middleContent, bottomContent string
-	middleContent = sysInfo
-
-	if config.Logo.EnableLogo {
-		switch config.Logo.Position {
-		case "above":
-			if topContent == "" {
-				topContent = logoOutput
-			} else {
-				topContent = topContent + "\n" + logoOutput
-			}
-		case "below":
-			if bottomContent == "" {
-				bottomContent = logoOutput
-			} else {
-				bottomContent = bottomContent + "\n" + logoOutput
-			}
-		}
-	}
-
-	if config.Image.EnableImage {
-		switch config.Image.Position {
-		case "above":
-			if topContent == "" {
-				topContent = imageOutput
-			} else {
-				topContent = topContent + "\n" + imageOutput
-			}
-		case "below":
-			if bottomContent == "" {
-				bottomContent = imageOutput
-			} else {
-				bottomContent = bottomContent + "\n" + imageOutput
-			}
-		}
-	}
-
-	var result strings.Builder
-
-	if topContent != "" {
-		result.WriteString(topContent + "\n")
-	}
-
-	if (config.Logo.EnableLogo && (config.Logo.Position == "left" || config.Logo.Position == "right")) ||
-		(config.Image.EnableImage && (config.Image.Position == "left" || config.Image.Position == "right")) {
-
-		if config.Logo.EnableLogo && config.Logo.Position == "right" &&
-			config.Image.EnableImage && config.Image.Position == "left" {
-
-			combined := mergeSideBySide(normalizeOutput(imageOutput), sysInfo)
-
-			result.WriteString(mergeSideBySide(combined, logoOutput))
-		} else if config.Logo.EnableLogo && config.Logo.Position == "left" &&
-			config.Image.EnableImage && config.Image.Position == "right" {
-
-			combined := mergeSideBySide(logoOutput, sysInfo)
-
-			result.WriteString(mergeSideBySide(combined, normalizeOutput(imageOutput)))
-		} else if config.Logo.EnableLogo && config.Logo.Position == "left" {
-
-			result.WriteString(mergeSideBySide(logoOutput, sysInfo))
-		} else if config.Logo.EnableLogo && config.Logo.Position == "right" {
-
-			result.WriteString(mergeSideBySide(sysInfo, logoOutput))
-		} else if config.Image.EnableImage && config.Image.Position == "left" {
-
-			normalizedImage := normalizeOutput(imageOutput)
-			result.WriteString(mergeSideBySide(normalizedImage, sysInfo))
-		} else if config.Image.EnableImage && config.Image.Position == "right" {
-
-			result.WriteString(mergeSideBySide(sysInfo, normalizeOutput(imageOutput)))
-		}
-	} else {
-
-		result.WriteString(middleContent)
-	}
-
-	if bottomContent != "" {
-		result.WriteString("\n" + bottomContent)
-	}
-
-	finalOutput := result.String()
-	if !strings.HasSuffix(finalOutput, "\n") {
-		finalOutput += "\n"
-	}
-
-	fmt.Print(finalOutput)
-}
-
-func mergeSideBySide(left, right string) string {
-	if left == "" {
-		return right
-	}
-	if right == "" {
-		return left
-	}
-
-	leftLines := strings.Split(strings.TrimRight(left, "\n"), "\n")
-	rightLines := strings.Split(strings.TrimRight(right, "\n"), "\n")
-
-	for i := range leftLines {
-		leftLines[i] = strings.TrimRight(leftLines[i], " ")
-	}
-	for i := range rightLines {
-		rightLines[i] = strings.TrimRight(rightLines[i], " ")
-	}
-
-	for len(leftLines) > 0 && strings.TrimSpace(leftLines[len(leftLines)-1]) == "" {
-		leftLines = leftLines[:len(leftLines)-1]
-	}
-	for len(rightLines) > 0 && strings.TrimSpace(rightLines[len(rightLines)-1]) == "" {
-		rightLines = rightLines[:len(rightLines)-1]
-	}
-
-	maxLeftWidth := 0
-	for _, line := range leftLines {
-		width := len([]rune(line))
-		if width > maxLeftWidth {
-			maxLeftWidth = width
-		}
-	}
-
-	padding := 2
-	totalPadding := maxLeftWidth + padding
-
-	var result strings.Builder
-	maxLines := len(leftLines)
-	if len(rightLines) > maxLines {
-		maxLines = len(rightLines)
-	}
-
-	for i := 0; i < maxLines; i++ {
-		var leftLine, rightLine string
-
-		if i < len(leftLines) {
-			leftLine = leftLines[i]
-		}
-		if i < len(rightLines) {
-			rightLine = rightLines[i]
-		}
-
-		if leftLine == "" && rightLine == "" {
-			continue
-		}
-
-		currentPadding := totalPadding - len([]rune(leftLine))
-		if currentPadding < 0 {
-			currentPadding = padding
-		}
-
-		if leftLine != "" {
-			result.WriteString(leftLine)
-			if rightLine != "" {
-				result.WriteString(strings.Repeat(" ", currentPadding))
-			}
-		} else if rightLine != "" {
-
-			result.WriteString(strings.Repeat(" ", totalPadding))
-		}
-
-		if rightLine != "" {
-			result.WriteString(rightLine)
-		}
-
-		if i < maxLines-1 {
-			result.WriteString("\n")
-		}
-	}
-
-	return result.String()
-}
-
-func normalizeOutput(output string) string {
-	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
-
-	for i := range lines {
-		lines[i] = strings.TrimRight(lines[i], " ")
-	}
-
-	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
-		lines = lines[:len(lines)-1]
-	}
-
-	maxLength := 0
-	for _, line := range lines {
-		lineLength := len([]rune(line))
-		if lineLength > maxLength {
-			maxLength = lineLength
-		}
-	}
-
-	for i, line := range lines {
-		currentLength := len([]rune(line))
-		if currentLength < maxLength {
-
-			lines[i] = line + strings.Repeat(" ", maxLength-currentLength)
-		}
-	}
-
-	return strings.Join(lines, "\n")
+	cols, rows := utils.TerminalSize()
+	fmt.Println(displayManager.Fit(logoOutput, imageOutput, cols, rows))
 }
@@ -1,50 +1,162 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
 )
 
+// DefaultCommandTimeout bounds every command that has no earlier deadline
+const DefaultCommandTimeout = 2 * time.Second
+
+// ErrCommandTimeout is returned when a command is killed because its
+// timeout or the fetch deadline passed
+var ErrCommandTimeout = errors.New("timeout")
+
+// Executor runs external commands. Components go through
+// GlobalCommandExecutor so tests can replace it with a fake.
+type Executor interface {
+	// Execute runs a command and returns its trimmed output, caching successful results
+	Execute(name string, args ...string) (string, error)
+	// ExecuteContext is Execute bound to ctx
+	ExecuteContext(ctx context.Context, name string, args ...string) (string, error)
+	// ExecuteWithStdin runs a command attached to the terminal's stdin, without caching
+	ExecuteWithStdin(name string, args ...string) (string, error)
+}
+
+// CommandEvent describes a finished command, passed to instrumentation hooks
+type CommandEvent struct {
+	Name     string
+	Args     []string
+	Duration time.Duration
+	// ExitCode is the command's exit status, or -1 if it did not exit normally
+	ExitCode int
+	// Cached is set when the output came from the cache without running the command
+	Cached bool
+	Err    error
+	// Source names the module the command ran for, if it went through For
+	Source string
+}
+
 // CommandExecutor provides optimized command execution with caching
 type CommandExecutor struct {
-	// No fields needed for now
+	// Cache holds successful command output; nil disables caching
+	Cache *Cache
+
+	timeout time.Duration
+	ctx     context.Context
+	hooks   []func(CommandEvent)
+	mutex   sync.RWMutex
 }
 
-// NewCommandExecutor creates a new command executor
+// NewCommandExecutor creates a new command executor backed by CommandCache
 func NewCommandExecutor() *CommandExecutor {
-	return &CommandExecutor{}
+	return &CommandExecutor{
+		Cache:   CommandCache,
+		timeout: DefaultCommandTimeout,
+		ctx:     context.Background(),
+	}
+}
+
+// SetTimeout sets the per-command timeout; zero or less restores the default
+func (c *CommandExecutor) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.timeout = timeout
+}
+
+// SetContext sets the context commands run by Execute are bound to, so a
+// fetch deadline also stops commands that are still running
+func (c *CommandExecutor) SetContext(ctx context.Context) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ctx = ctx
+}
+
+// AddHook registers fn to be called after every command, including cache hits
+func (c *CommandExecutor) AddHook(fn func(CommandEvent)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hooks = append(c.hooks, fn)
+}
+
+func (c *CommandExecutor) settings() (context.Context, time.Duration) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.ctx, c.timeout
+}
+
+// For returns an Executor that runs commands through c and reports them to
+// the hooks with Source set to source
+func (c *CommandExecutor) For(source string) Executor {
+	return &sourceExecutor{executor: c, source: source}
+}
+
+func (c *CommandExecutor) notify(event CommandEvent) {
+	c.mutex.RLock()
+	hooks := c.hooks
+	c.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		hook(event)
+	}
 }
 
 // Execute executes a command and returns its output
 func (c *CommandExecutor) Execute(name string, args ...string) (string, error) {
+	ctx, _ := c.settings()
+	return c.ExecuteContext(ctx, name, args...)
+}
+
+// ExecuteContext executes a command bound to ctx and the per-command timeout
+func (c *CommandExecutor) ExecuteContext(ctx context.Context, name string, args ...string) (string, error) {
+	return c.executeContext(ctx, "", name, args...)
+}
+
+func (c *CommandExecutor) executeContext(ctx context.Context, source, name string, args ...string) (string, error) {
 	// Create a cache key from the command and arguments
 	cacheKey := fmt.Sprintf("cmd:%s:%s", name, strings.Join(args, ":"))
 
 	// Check if the result is in the cache
-	if cachedResult, found := CommandCache.Get(cacheKey); found {
-		return cachedResult.(string), nil
+	if c.Cache != nil {
+		if cachedResult, found := c.Cache.Get(cacheKey); found {
+			c.notify(CommandEvent{Name: name, Args: args, Cached: true, Source: source})
+			return cachedResult.(string), nil
+		}
 	}
 
-	// Execute the command
-	cmd := exec.Command(name, args...)
-	output, err := cmd.Output()
+	output, err := c.run(ctx, nil, source, name, args...)
 	if err != nil {
 		return "", err
 	}
 
 	// Cache the result
 	result := strings.TrimSpace(string(output))
-	CommandCache.Set(cacheKey, result)
+	if c.Cache != nil {
+		c.Cache.Set(cacheKey, result)
+	}
 
 	return result, nil
 }
 
-// ExecuteWithStdin executes a command with stdin and returns its output
+// ExecuteWithStdin executes a command attached to stdin, as needed by tput
+// and stty to query the terminal, and returns its output
 func (c *CommandExecutor) ExecuteWithStdin(name string, args ...string) (string, error) {
+	return c.executeWithStdin("", name, args...)
+}
+
+func (c *CommandExecutor) executeWithStdin(source, name string, args ...string) (string, error) {
 	// Commands with stdin cannot be cached reliably
-	cmd := exec.Command(name, args...)
-	output, err := cmd.Output()
+	ctx, _ := c.settings()
+	output, err := c.run(ctx, os.Stdin, source, name, args...)
 	if err != nil {
 		return "", err
 	}
@@ -52,5 +164,58 @@ func (c *CommandExecutor) ExecuteWithStdin(name string, args ...string) (string,
 	return strings.TrimSpace(string(output)), nil
 }
 
-// GlobalCommandExecutor is a global instance of CommandExecutor
-var GlobalCommandExecutor = NewCommandExecutor()
+func (c *CommandExecutor) run(ctx context.Context, stdin *os.File, source, name string, args ...string) ([]byte, error) {
+	_, timeout := c.settings()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	// Don't wait for grandchildren that inherited stdout after the kill
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	start := time.Now()
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		err = fmt.Errorf("%s: %w", name, ErrCommandTimeout)
+	}
+
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	c.notify(CommandEvent{Name: name, Args: args, Duration: time.Since(start), ExitCode: exitCode, Err: err, Source: source})
+
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// sourceExecutor is a CommandExecutor whose events carry a fixed Source
+type sourceExecutor struct {
+	executor *CommandExecutor
+	source   string
+}
+
+func (s *sourceExecutor) Execute(name string, args ...string) (string, error) {
+	ctx, _ := s.executor.settings()
+	return s.executor.executeContext(ctx, s.source, name, args...)
+}
+
+func (s *sourceExecutor) ExecuteContext(ctx context.Context, name string, args ...string) (string, error) {
+	return s.executor.executeContext(ctx, s.source, name, args...)
+}
+
+func (s *sourceExecutor) ExecuteWithStdin(name string, args ...string) (string, error) {
+	return s.executor.executeWithStdin(s.source, name, args...)
+}
+
+// DefaultExecutor is the process-wide CommandExecutor; its timeout, context
+// and hooks are configured by the display manager
+var DefaultExecutor = NewCommandExecutor()
+
+// GlobalCommandExecutor is the Executor components run commands through
+var GlobalCommandExecutor Executor = DefaultExecutor
@@ -0,0 +1,169 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiskCache stores JSON encoded values in files below Dir so they survive
+// between runs. Every value is stored under a name together with an
+// invalidation key; a lookup only hits when the key matches and the entry
+// has not expired.
+type DiskCache struct {
+	Dir string
+}
+
+// DiskCacheEntry describes a stored value
+type DiskCacheEntry struct {
+	Name    string
+	Key     string
+	Created time.Time
+	Expires time.Time
+	Size    int64
+}
+
+type diskCacheFile struct {
+	Name    string          `json:"name"`
+	Key     string          `json:"key"`
+	Created time.Time       `json:"created"`
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// NewDiskCache creates a cache storing its files in dir
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{Dir: dir}
+}
+
+// DefaultCacheDir returns $XDG_CACHE_HOME/lunarfetch, or ~/.cache/lunarfetch
+func DefaultCacheDir() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, _ := os.UserHomeDir()
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "lunarfetch")
+}
+
+// Get decodes the value stored under name into value if it is still valid for key
+func (c *DiskCache) Get(name, key string, value interface{}) bool {
+	data, err := os.ReadFile(c.path(name))
+	if err != nil {
+		return false
+	}
+
+	var file diskCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return false
+	}
+	if file.Name != name || file.Key != key || time.Now().After(file.Expires) {
+		return false
+	}
+
+	return json.Unmarshal(file.Value, value) == nil
+}
+
+// Set stores value under name, valid for ttl or until key changes
+func (c *DiskCache) Set(name, key string, ttl time.Duration, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(diskCacheFile{
+		Name:    name,
+		Key:     key,
+		Created: now,
+		Expires: now.Add(ttl),
+		Value:   encoded,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(name))
+}
+
+// Entries lists the stored values sorted by name
+func (c *DiskCache) Entries() ([]DiskCacheEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DiskCacheEntry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var file diskCacheFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			continue
+		}
+		entries = append(entries, DiskCacheEntry{
+			Name:    file.Name,
+			Key:     file.Key,
+			Created: file.Created,
+			Expires: file.Expires,
+			Size:    int64(len(data)),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Clear removes every stored value and returns how many were removed
+func (c *DiskCache) Clear() (int, error) {
+	paths, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// path maps a name to a file name, replacing characters that are not
+// safe in file names
+func (c *DiskCache) path(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	return filepath.Join(c.Dir, safe+".json")
+}
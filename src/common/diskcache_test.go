@@ -0,0 +1,134 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskCacheGet(t *testing.T) {
+	tests := []struct {
+		name     string
+		setName  string
+		setKey   string
+		ttl      time.Duration
+		getName  string
+		getKey   string
+		wantHit  bool
+		wantData string
+	}{
+		{"hit", "module:cpu", "k1", time.Hour, "module:cpu", "k1", true, "value"},
+		{"key changed", "module:cpu", "k1", time.Hour, "module:cpu", "k2", false, ""},
+		{"expired", "module:cpu", "k1", -time.Second, "module:cpu", "k1", false, ""},
+		{"not stored", "module:cpu", "k1", time.Hour, "module:gpu", "k1", false, ""},
+		// Both names map to the same file, which remembers the name it holds
+		{"file name shared", "module:cpu", "k1", time.Hour, "module_cpu", "k1", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewDiskCache(t.TempDir())
+			if err := cache.Set(tt.setName, tt.setKey, tt.ttl, "value"); err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if hit := cache.Get(tt.getName, tt.getKey, &got); hit != tt.wantHit || got != tt.wantData {
+				t.Errorf("Get = %v, %q, want %v, %q", hit, got, tt.wantHit, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestDiskCacheGetUnreadable(t *testing.T) {
+	tests := map[string]string{
+		"corrupt file": "{not json",
+		"wrong type":   `{"name": "module:cpu", "key": "k1", "expires": "2999-01-01T00:00:00Z", "value": {"a": 1}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := NewDiskCache(t.TempDir())
+			if err := os.WriteFile(cache.path("module:cpu"), []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if cache.Get("module:cpu", "k1", &got) {
+				t.Errorf("Get hit with %q", got)
+			}
+		})
+	}
+}
+
+func TestDiskCacheSetReplacesAtomically(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "lunarfetch")
+	cache := NewDiskCache(dir)
+
+	for _, value := range []string{"first", "second"} {
+		if err := cache.Set("shell-version:/usr/bin/zsh", "stamp", time.Hour, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got string
+	if !cache.Get("shell-version:/usr/bin/zsh", "stamp", &got) || got != "second" {
+		t.Errorf("Get = %q, want the last value set", got)
+	}
+
+	// The temporary file is renamed over the entry, leaving one file
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "shell-version__usr_bin_zsh.json" {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestDiskCacheSetUnencodable(t *testing.T) {
+	cache := NewDiskCache(t.TempDir())
+	if err := cache.Set("module:cpu", "k1", time.Hour, func() {}); err == nil {
+		t.Error("Set stored a value JSON cannot encode")
+	}
+}
+
+func TestDiskCacheEntriesAndClear(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewDiskCache(dir)
+	for _, name := range []string{"module:gpu", "module:cpu"} {
+		if err := cache.Set(name, "key-"+name, time.Hour, strings.Repeat("x", 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Leftovers of an interrupted write and unreadable files are not entries
+	for name, data := range map[string]string{".tmp-123": "{", "broken.json": "{"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "module:cpu" || entries[1].Name != "module:gpu" {
+		t.Fatalf("Entries = %+v", entries)
+	}
+	for _, entry := range entries {
+		if entry.Key != "key-"+entry.Name || entry.Size == 0 || !entry.Expires.After(entry.Created) {
+			t.Errorf("entry = %+v", entry)
+		}
+	}
+
+	// Clear removes every .json file, the unreadable one included
+	removed, err := cache.Clear()
+	if err != nil || removed != 3 {
+		t.Errorf("Clear = %d, %v, want 3", removed, err)
+	}
+	if entries, _ := cache.Entries(); len(entries) != 0 {
+		t.Errorf("Entries after Clear = %+v", entries)
+	}
+	if removed, err := NewDiskCache(filepath.Join(dir, "missing")).Clear(); removed != 0 || err != nil {
+		t.Errorf("Clear of a missing directory = %d, %v", removed, err)
+	}
+}
@@ -1,43 +1,226 @@
 package components
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path"
+	"sort"
 	"strings"
-
-	"lunarfetch/src/common"
+	"time"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "battery",
+		Label:    "Battery",
+		Icon:     "󰂄",
+		Enabled:  true,
+		Order:    110,
+		Priority: 30,
+		New: func() InfoProvider {
+			return &BatteryInfo{SystemInfo: SystemInfo{Name: "Battery"}, Options: DefaultBatteryOptions()}
+		},
+	})
+}
+
+// BatteryOptions configures what is shown for each battery
+type BatteryOptions struct {
+	// Time shows the estimated time until empty or full
+	Time bool `json:"time"`
+	// Health shows the current full capacity relative to the design capacity
+	Health bool `json:"health"`
+	// AC adds a line with the AC adapter state
+	AC bool `json:"ac"`
+}
+
+// DefaultBatteryOptions returns the options used when config.json sets none
+func DefaultBatteryOptions() BatteryOptions {
+	return BatteryOptions{Time: true}
+}
+
+// Battery describes a single battery exposed under /sys/class/power_supply
+type Battery struct {
+	Name      string
+	Capacity  int
+	Status    string
+	Remaining time.Duration
+	Health    float64
+}
+
 // BatteryInfo provides battery status information
 type BatteryInfo struct {
 	SystemInfo
+	Options BatteryOptions
+}
+
+// Configure applies the "battery" options from config.json
+func (b *BatteryInfo) Configure(options json.RawMessage) error {
+	opts := DefaultBatteryOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	b.Options = opts
+	return nil
 }
 
-// GetInfo returns the battery status
-func (b *BatteryInfo) GetInfo() string {
-	if _, err := os.Stat("/sys/class/power_supply/BAT0"); err != nil {
-		return "No battery"
+// GetInfo returns the status of every battery and the AC adapter
+func (b *BatteryInfo) GetInfo() Result {
+	fsys := b.rootFS()
+	supplyDir := path.Join(sysfsDir, "class", "power_supply")
+
+	entries, err := fs.ReadDir(fsys, supplyDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ErrorResult(err)
 	}
 
-	capacity, err := os.ReadFile("/sys/class/power_supply/BAT0/capacity")
-	if err != nil {
-		// Try using command execution as fallback
-		out, err := common.GlobalCommandExecutor.Execute("cat", "/sys/class/power_supply/BAT0/capacity")
-		if err != nil {
-			return "Unknown"
+	var batteries []Battery
+	acFound, acOnline := false, false
+
+	for _, entry := range entries {
+		dir := path.Join(supplyDir, entry.Name())
+
+		switch readSysfsString(fsys, dir, "type") {
+		case "Battery":
+			// Peripheral batteries (mice, headsets) report scope "Device"
+			if readSysfsString(fsys, dir, "scope") == "Device" {
+				continue
+			}
+			if battery, ok := readBattery(fsys, entry.Name(), dir); ok {
+				batteries = append(batteries, battery)
+			}
+		case "Mains", "USB", "USB_C":
+			if online, ok := readSysfsInt(fsys, dir, "online"); ok {
+				acFound = true
+				acOnline = acOnline || online == 1
+			}
+		}
+	}
+
+	sort.Slice(batteries, func(i, j int) bool {
+		return batteries[i].Name < batteries[j].Name
+	})
+
+	fields := Fields{"present": len(batteries) > 0}
+	if acFound {
+		fields["ac_online"] = acOnline
+	}
+
+	if len(batteries) == 0 {
+		return NewResult("No battery", fields)
+	}
+
+	details := make([]Fields, 0, len(batteries))
+	for _, battery := range batteries {
+		detail := Fields{
+			"name":     battery.Name,
+			"percent":  battery.Capacity,
+			"status":   battery.Status,
+			"health":   battery.Health,
+			"seconds":  int(battery.Remaining.Seconds()),
+			"charging": battery.Status == "Charging",
 		}
-		capacity = []byte(out)
+		details = append(details, detail)
 	}
+	fields["batteries"] = details
+	fields["percent"] = batteries[0].Capacity
+	fields["status"] = batteries[0].Status
 
-	status, err := os.ReadFile("/sys/class/power_supply/BAT0/status")
-	if err != nil {
-		// Try using command execution as fallback
-		out, err := common.GlobalCommandExecutor.Execute("cat", "/sys/class/power_supply/BAT0/status")
-		if err != nil {
-			return fmt.Sprintf("%s%%", strings.TrimSpace(string(capacity)))
+	result := NewResult("", fields)
+	if len(batteries) == 1 {
+		result.Value = b.formatBattery(batteries[0])
+	} else {
+		for _, battery := range batteries {
+			result.Lines = append(result.Lines, Line{
+				Label: fmt.Sprintf("Battery (%s)", battery.Name),
+				Value: b.formatBattery(battery),
+			})
 		}
-		status = []byte(out)
 	}
 
-	return fmt.Sprintf("%s%% (%s)", strings.TrimSpace(string(capacity)), strings.TrimSpace(string(status)))
+	if b.Options.AC && acFound {
+		state := "Disconnected"
+		if acOnline {
+			state = "Connected"
+		}
+		result.Lines = append(result.Lines, Line{Label: "AC", Value: state})
+	}
+
+	return result
+}
+
+// formatBattery renders a battery as "85% (Discharging, 2h 10m left)"
+func (b *BatteryInfo) formatBattery(battery Battery) string {
+	details := []string{}
+	if battery.Status != "" {
+		details = append(details, battery.Status)
+	}
+	if b.Options.Time && battery.Remaining > 0 {
+		suffix := "left"
+		if battery.Status == "Charging" {
+			suffix = "until full"
+		}
+		details = append(details, fmt.Sprintf("%s %s", formatDuration(battery.Remaining), suffix))
+	}
+	if b.Options.Health && battery.Health > 0 {
+		details = append(details, fmt.Sprintf("health %.0f%%", battery.Health))
+	}
+
+	if len(details) == 0 {
+		return fmt.Sprintf("%d%%", battery.Capacity)
+	}
+	return fmt.Sprintf("%d%% (%s)", battery.Capacity, strings.Join(details, ", "))
+}
+
+// readBattery collects the state of the battery in dir. Drivers expose either
+// energy_* (µWh, µW) or charge_* (µAh, µA) files; ratios work with either.
+func readBattery(fsys fs.FS, name, dir string) (Battery, bool) {
+	battery := Battery{Name: name, Status: readSysfsString(fsys, dir, "status")}
+
+	now, hasNow := readSysfsInt(fsys, dir, "energy_now")
+	full, hasFull := readSysfsInt(fsys, dir, "energy_full")
+	design, hasDesign := readSysfsInt(fsys, dir, "energy_full_design")
+	rate, hasRate := readSysfsInt(fsys, dir, "power_now")
+	if !hasNow {
+		now, hasNow = readSysfsInt(fsys, dir, "charge_now")
+		full, hasFull = readSysfsInt(fsys, dir, "charge_full")
+		design, hasDesign = readSysfsInt(fsys, dir, "charge_full_design")
+		rate, hasRate = readSysfsInt(fsys, dir, "current_now")
+	}
+
+	if capacity, ok := readSysfsInt(fsys, dir, "capacity"); ok {
+		battery.Capacity = int(capacity)
+	} else if hasNow && hasFull && full > 0 {
+		battery.Capacity = int(now * 100 / full)
+	} else {
+		return battery, false
+	}
+
+	if hasFull && hasDesign && design > 0 {
+		battery.Health = float64(full) / float64(design) * 100
+	}
+
+	if hasNow && hasRate && rate > 0 {
+		switch battery.Status {
+		case "Discharging":
+			battery.Remaining = time.Duration(float64(now) / float64(rate) * float64(time.Hour))
+		case "Charging":
+			if hasFull && full > now {
+				battery.Remaining = time.Duration(float64(full-now) / float64(rate) * float64(time.Hour))
+			}
+		}
+	}
+
+	return battery, true
+}
+
+// formatDuration renders a duration as "2h 10m" or "45m"
+func formatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
 }
@@ -0,0 +1,115 @@
+package components
+
+import (
+	"encoding/json"
+	"path"
+	"testing"
+)
+
+const powerSupplyDir = "sys/class/power_supply"
+
+// powerSupply returns the sysfs attribute files of one power supply
+func powerSupply(name string, attributes map[string]string) map[string]string {
+	files := make(map[string]string)
+	for attribute, value := range attributes {
+		files[path.Join(powerSupplyDir, name, attribute)] = value + "\n"
+	}
+	return files
+}
+
+func newBatteryInfo(t *testing.T, options string, supplies ...map[string]string) *BatteryInfo {
+	t.Helper()
+	files := make(map[string]string)
+	for _, supply := range supplies {
+		for name, data := range supply {
+			files[name] = data
+		}
+	}
+	info := &BatteryInfo{SystemInfo: SystemInfo{FS: newFixtureFS(files), Exec: newFakeExecutor(nil)}, Options: DefaultBatteryOptions()}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// energyBattery reports energy_* in µWh and µW, as most laptops do
+var energyBattery = powerSupply("BAT0", map[string]string{
+	"type":               "Battery",
+	"status":             "Discharging",
+	"capacity":           "85",
+	"energy_now":         "42500000",
+	"energy_full":        "50000000",
+	"energy_full_design": "57000000",
+	"power_now":          "15000000",
+})
+
+// chargeBattery reports charge_* in µAh and µA and has no capacity file
+var chargeBattery = powerSupply("BAT1", map[string]string{
+	"type":               "Battery",
+	"status":             "Charging",
+	"charge_now":         "2000000",
+	"charge_full":        "4000000",
+	"charge_full_design": "4000000",
+	"current_now":        "1000000",
+})
+
+var acAdapter = powerSupply("AC", map[string]string{
+	"type":   "Mains",
+	"online": "1",
+})
+
+var mouseBattery = powerSupply("hidpp_battery_0", map[string]string{
+	"type":     "Battery",
+	"scope":    "Device",
+	"status":   "Discharging",
+	"capacity": "40",
+})
+
+func TestBatteryInfoEnergy(t *testing.T) {
+	result := newBatteryInfo(t, `{"time": true, "health": true}`, energyBattery, mouseBattery).GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if want := "85% (Discharging, 2h 50m left, health 88%)"; result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+	if len(result.Lines) != 0 {
+		t.Errorf("peripheral battery was listed: %v", result.Lines)
+	}
+}
+
+func TestBatteryInfoMultipleWithAC(t *testing.T) {
+	result := newBatteryInfo(t, `{"time": true, "ac": true}`, energyBattery, chargeBattery, acAdapter).GetInfo()
+
+	want := []Line{
+		{"Battery (BAT0)", "85% (Discharging, 2h 50m left)"},
+		{"Battery (BAT1)", "50% (Charging, 2h 0m until full)"},
+		{"AC", "Connected"},
+	}
+	if len(result.Lines) != len(want) {
+		t.Fatalf("Lines = %v", result.Lines)
+	}
+	for i, line := range want {
+		if result.Lines[i] != line {
+			t.Errorf("line %d = %v, want %v", i, result.Lines[i], line)
+		}
+	}
+	if result.Fields["ac_online"] != true || result.Fields["percent"] != 85 {
+		t.Errorf("Fields = %v", result.Fields)
+	}
+}
+
+func TestBatteryInfoWithoutBattery(t *testing.T) {
+	result := newBatteryInfo(t, "", acAdapter).GetInfo()
+	if result.Err != nil || result.Value != "No battery" {
+		t.Errorf("got %q, %v", result.Value, result.Err)
+	}
+
+	// Desktops without any power supply class
+	result = newBatteryInfo(t, "").GetInfo()
+	if result.Err != nil || result.Value != "No battery" {
+		t.Errorf("empty sysfs: got %q, %v", result.Value, result.Err)
+	}
+}
@@ -1,23 +1,97 @@
 package components
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"lunarfetch/src/common"
 )
 
+// ErrNotFound is returned when a component cannot detect the requested information
+var ErrNotFound = errors.New("information not available")
+
 // InfoProvider is an interface for components that provide system information
 type InfoProvider interface {
-	GetInfo() string
+	GetInfo() Result
 	GetName() string
 }
 
-// SystemInfo provides basic system information
-type SystemInfo struct {
-	Name string
+// Configurable is implemented by components that accept options from the
+// "options" section of config.json, keyed by their registry key
+type Configurable interface {
+	Configure(options json.RawMessage) error
+}
+
+// Cacheable is implemented by components whose results may be reused from
+// the disk cache. CacheKey returns a key that changes whenever a stored
+// result becomes stale, and false when the current result must not be cached.
+type Cacheable interface {
+	CacheKey() (string, bool)
+}
+
+// Refreshable is implemented by cacheable components with fields that change
+// while the system runs, such as a clock speed. The disk cache stores results
+// without these fields and LiveFields reads them again on every run.
+type Refreshable interface {
+	LiveFields() Fields
+}
+
+// Fields holds the typed values collected by a component, keyed by field name
+type Fields map[string]interface{}
+
+// Result is the structured output of an InfoProvider
+type Result struct {
+	// Value is the default human-readable rendering
+	Value string
+	// Fields holds the typed data the value was rendered from
+	Fields Fields
+	// Lines holds additional labelled lines rendered below the value
+	Lines []Line
+	// Summary marks Value as a summary of Lines, which the info box shows
+	// instead of it
+	Summary bool
+	// Err is set when the information could not be collected
+	Err error
+}
+
+// Line is an additional labelled line of a result
+type Line struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
 }
 
-// GetName returns the name of this info provider
-func (s *SystemInfo) GetName() string {
-	return s.Name
+// NewResult creates a successful result with the given rendering and fields
+func NewResult(value string, fields Fields) Result {
+	if fields == nil {
+		fields = Fields{}
+	}
+	return Result{Value: value, Fields: fields}
+}
+
+// ErrorResult creates a result for information that could not be collected
+func ErrorResult(err error) Result {
+	if err == nil {
+		err = ErrNotFound
+	}
+	if errors.Is(err, common.ErrCommandTimeout) {
+		return TimeoutResult()
+	}
+	return Result{Value: "Unknown", Fields: Fields{}, Err: err}
+}
+
+// TimeoutResult creates a result for a component that did not finish in time
+func TimeoutResult() Result {
+	return Result{Value: "timeout", Fields: Fields{}, Err: common.ErrCommandTimeout}
+}
+
+// String returns the default rendering of the result
+func (r Result) String() string {
+	return r.Value
 }
 
 // FormatBytes formats bytes to a human-readable string
@@ -35,3 +109,69 @@ func FormatBytes(bytes uint64) string {
 	}
 	return fmt.Sprintf("%.2f %s", value, unit)
 }
+
+// percentOf returns used as a percentage of total, or 0 when total is zero
+func percentOf(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total) * 100
+}
+
+// ExpandTemplate replaces {name} placeholders in tmpl with the matching
+// values. Placeholders without a value are left untouched.
+func ExpandTemplate(tmpl string, values map[string]string) string {
+	var b strings.Builder
+
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(tmpl[:start])
+		if value, ok := values[tmpl[start+1:end]]; ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+
+	b.WriteString(tmpl)
+	return b.String()
+}
+
+// readSysfsString returns the trimmed content of a sysfs attribute, or "" if it cannot be read
+func readSysfsString(fsys fs.FS, dir, name string) string {
+	data, err := fs.ReadFile(fsys, path.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsInt parses a numeric sysfs attribute. Some drivers report a
+// negative current while discharging, so the magnitude is returned.
+func readSysfsInt(fsys fs.FS, dir, name string) (int64, bool) {
+	value, err := strconv.ParseInt(readSysfsString(fsys, dir, name), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if value < 0 {
+		value = -value
+	}
+	return value, true
+}
+
+// bootID returns the kernel's random ID for the current boot, which cached
+// hardware information is keyed to
+func bootID(fsys fs.FS) (string, bool) {
+	id := readSysfsString(fsys, path.Join(procDir, "sys", "kernel", "random"), "boot_id")
+	return id, id != ""
+}
@@ -0,0 +1,74 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"lunarfetch/src/common"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := map[string]string{"name": "Arch Linux", "arch": "x86_64", "empty": ""}
+	tests := map[string]string{
+		"{name} {arch}":      "Arch Linux x86_64",
+		"{name}{empty}!":     "Arch Linux!",
+		"{unknown} {name}":   "{unknown} Arch Linux",
+		"unclosed {name":     "unclosed {name",
+		"no placeholders":    "no placeholders",
+		"{name} ({arch}) {}": "Arch Linux (x86_64) {}",
+	}
+	for tmpl, want := range tests {
+		if got := ExpandTemplate(tmpl, values); got != want {
+			t.Errorf("ExpandTemplate(%q) = %q, want %q", tmpl, got, want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:           "512.00 B",
+		1536:          "1.50 KB",
+		268435456:     "256.00 MB",
+		1073741824:    "1.00 GB",
+		2147483648000: "1.95 TB",
+	}
+	for bytes, want := range tests {
+		if got := FormatBytes(bytes); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestErrorResult(t *testing.T) {
+	if result := ErrorResult(nil); result.Value != "Unknown" || !errors.Is(result.Err, ErrNotFound) {
+		t.Errorf("ErrorResult(nil) = %q, %v", result.Value, result.Err)
+	}
+
+	timeout := fmt.Errorf("lspci: %w", common.ErrCommandTimeout)
+	if result := ErrorResult(timeout); result.Value != "timeout" {
+		t.Errorf("ErrorResult(timeout) = %q", result.Value)
+	}
+}
+
+func TestReadSysfs(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"sys/class/power_supply/BAT0/capacity": "85\n",
+		"sys/class/power_supply/BAT0/status":   "  Full \n",
+		"sys/class/power_supply/BAT0/model":    "not a number\n",
+	})
+	dir := "sys/class/power_supply/BAT0"
+
+	if got := readSysfsString(fsys, dir, "status"); got != "Full" {
+		t.Errorf("status = %q", got)
+	}
+	if got, ok := readSysfsInt(fsys, dir, "capacity"); !ok || got != 85 {
+		t.Errorf("capacity = %d, %v", got, ok)
+	}
+	if _, ok := readSysfsInt(fsys, dir, "model"); ok {
+		t.Error("non-numeric attribute parsed as an integer")
+	}
+	if _, ok := readSysfsInt(fsys, dir, "missing"); ok {
+		t.Error("missing attribute reported as present")
+	}
+}
@@ -1,28 +1,389 @@
 package components
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "cpu",
+		Label:    "CPU",
+		Icon:     "󰘚",
+		Enabled:  true,
+		Order:    130,
+		Priority: 70,
+		CacheTTL: 7 * 24 * time.Hour,
+		New: func() InfoProvider {
+			return &CPUInfo{SystemInfo: SystemInfo{Name: "CPU"}, Options: DefaultCPUOptions()}
+		},
+	})
+}
+
+// CPUOptions configures how the CPU is rendered
+type CPUOptions struct {
+	// Format is a template using {model}, {vendor}, {cores}, {threads},
+	// {cur_mhz}, {cur_ghz}, {max_mhz}, {max_ghz} and {temp}
+	Format string `json:"format"`
+}
+
+// DefaultCPUOptions returns the options used when config.json sets none
+func DefaultCPUOptions() CPUOptions {
+	return CPUOptions{Format: "{model}"}
+}
+
+// CPUDetails holds everything collected about the processor
+type CPUDetails struct {
+	Model       string
+	Vendor      string
+	Hardware    string
+	Parts       []string
+	Cores       int
+	Threads     int
+	CurrentMHz  float64
+	MaxMHz      float64
+	Temperature float64
+}
+
 // CPUInfo provides CPU information
 type CPUInfo struct {
 	SystemInfo
+	Options CPUOptions
+}
+
+// Configure applies the "cpu" options from config.json
+func (c *CPUInfo) Configure(options json.RawMessage) error {
+	opts := DefaultCPUOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	if opts.Format == "" {
+		opts.Format = DefaultCPUOptions().Format
+	}
+	c.Options = opts
+	return nil
 }
 
-// GetInfo returns the CPU model
-func (c *CPUInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("lscpu")
+// CacheKey keys cached results to the boot, as the CPU cannot change without
+// a reboot. Formats showing the live frequency or temperature are not cached.
+func (c *CPUInfo) CacheKey() (string, bool) {
+	for _, volatile := range []string{"{cur_mhz}", "{cur_ghz}", "{temp}"} {
+		if strings.Contains(c.Options.Format, volatile) {
+			return "", false
+		}
+	}
+	return bootID(c.rootFS())
+}
+
+// LiveFields reads the current frequency and temperature, which a cached
+// result must not carry over from an earlier run
+func (c *CPUInfo) LiveFields() Fields {
+	fsys := c.rootFS()
+	var details CPUDetails
+	readCPUFrequency(fsys, path.Join(sysfsDir, "devices", "system", "cpu"), &details)
+	return Fields{
+		"current_mhz":   details.CurrentMHz,
+		"temperature_c": readCPUTemperature(fsys),
+	}
+}
+
+// GetInfo returns the CPU model, topology, frequency and temperature
+func (c *CPUInfo) GetInfo() Result {
+	fsys := c.rootFS()
+
+	var details CPUDetails
+	if file, err := fsys.Open(path.Join(procDir, "cpuinfo")); err == nil {
+		details, _ = parseCPUInfo(file)
+		file.Close()
+	}
+
+	if details.Model == "" {
+		details.Model = lscpuModel(c.executor())
+	}
+	if details.Model == "" {
+		return ErrorResult(ErrNotFound)
+	}
+
+	cpuDir := path.Join(sysfsDir, "devices", "system", "cpu")
+	readCPUTopology(fsys, cpuDir, &details)
+	readCPUFrequency(fsys, cpuDir, &details)
+	details.Temperature = readCPUTemperature(fsys)
+
+	values := map[string]string{
+		"model":   details.Model,
+		"vendor":  details.Vendor,
+		"cores":   optionalInt(details.Cores),
+		"threads": optionalInt(details.Threads),
+		"cur_mhz": optionalFloat(details.CurrentMHz, "%.0f"),
+		"cur_ghz": optionalFloat(details.CurrentMHz/1000, "%.2f"),
+		"max_mhz": optionalFloat(details.MaxMHz, "%.0f"),
+		"max_ghz": optionalFloat(details.MaxMHz/1000, "%.2f"),
+		"temp":    optionalFloat(details.Temperature, "%.1f"),
+	}
+
+	return NewResult(ExpandTemplate(c.Options.Format, values), Fields{
+		"model":         details.Model,
+		"vendor":        details.Vendor,
+		"hardware":      details.Hardware,
+		"parts":         details.Parts,
+		"cores":         details.Cores,
+		"threads":       details.Threads,
+		"current_mhz":   details.CurrentMHz,
+		"max_mhz":       details.MaxMHz,
+		"temperature_c": details.Temperature,
+	})
+}
+
+var (
+	cpuFrequencySuffix = regexp.MustCompile(`\s*@\s*[\d.]+\s*[GM]Hz\s*$`)
+	cpuTrademarks      = strings.NewReplacer("(R)", "", "(r)", "", "(TM)", "", "(tm)", "")
+)
+
+// cleanCPUModel strips trademarks and the nominal frequency from a model name
+func cleanCPUModel(model string) string {
+	model = cpuTrademarks.Replace(model)
+	model = cpuFrequencySuffix.ReplaceAllString(model, "")
+	return strings.Join(strings.Fields(model), " ")
+}
+
+// parseCPUInfo reads /proc/cpuinfo formatted data. x86 kernels report a
+// "model name" per processor; ARM kernels often only give implementer and
+// part numbers, which are mapped to core names.
+func parseCPUInfo(r io.Reader) (CPUDetails, error) {
+	var details CPUDetails
+	var implementer, legacyModel string
+	physicalID := ""
+	cores := make(map[string]bool)
+	seenParts := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "processor":
+			if _, err := strconv.Atoi(value); err == nil {
+				details.Threads++
+			}
+		case "Processor":
+			legacyModel = value
+		case "model name", "cpu model":
+			if details.Model == "" {
+				details.Model = value
+			}
+		case "vendor_id":
+			details.Vendor = map[string]string{"GenuineIntel": "Intel", "AuthenticAMD": "AMD"}[value]
+			if details.Vendor == "" {
+				details.Vendor = value
+			}
+		case "physical id":
+			physicalID = value
+		case "core id":
+			cores[physicalID+":"+value] = true
+		case "cpu MHz":
+			if mhz, err := strconv.ParseFloat(value, 64); err == nil && mhz > details.CurrentMHz {
+				details.CurrentMHz = mhz
+			}
+		case "Hardware":
+			details.Hardware = value
+		case "CPU implementer":
+			implementer = value
+			if details.Vendor == "" {
+				details.Vendor = armVendor(value)
+			}
+		case "CPU part":
+			if name := armPartName(implementer, value); name != "" && !seenParts[name] {
+				seenParts[name] = true
+				details.Parts = append(details.Parts, name)
+			}
+		}
+	}
+
+	if details.Model == "" || strings.HasPrefix(details.Model, "ARMv") {
+		armModel := strings.Join(details.Parts, " + ")
+		switch {
+		case details.Hardware != "" && armModel != "":
+			details.Model = fmt.Sprintf("%s (%s)", details.Hardware, armModel)
+		case details.Hardware != "":
+			details.Model = details.Hardware
+		case armModel != "":
+			details.Model = armModel
+		case details.Model == "":
+			details.Model = legacyModel
+		}
+	}
+
+	details.Model = cleanCPUModel(details.Model)
+	details.Cores = len(cores)
+	return details, scanner.Err()
+}
+
+// lscpuModel returns the model reported by lscpu, used when /proc/cpuinfo is unavailable
+func lscpuModel(executor common.Executor) string {
+	out, err := executor.Execute("lscpu")
 	if err != nil {
-		return "Unknown"
+		return ""
 	}
-	lines := strings.Split(out, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "Model name:") {
-			fields := strings.Fields(line)
-			return strings.Join(fields[2:], " ")
+	for _, line := range strings.Split(out, "\n") {
+		if key, value, found := strings.Cut(line, ":"); found && strings.TrimSpace(key) == "Model name" {
+			return cleanCPUModel(value)
 		}
 	}
-	return "Unknown"
+	return ""
+}
+
+// cpuDirectories lists the cpuN directories below /sys/devices/system/cpu
+func cpuDirectories(fsys fs.FS, cpuDir string) []string {
+	entries, err := fs.ReadDir(fsys, cpuDir)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "cpu") {
+			continue
+		}
+		if _, err := strconv.Atoi(strings.TrimPrefix(name, "cpu")); err != nil {
+			continue
+		}
+		dirs = append(dirs, path.Join(cpuDir, name))
+	}
+	return dirs
+}
+
+// readCPUTopology counts threads and physical cores from sysfs, keeping the
+// /proc/cpuinfo figures when sysfs has no topology information
+func readCPUTopology(fsys fs.FS, cpuDir string, details *CPUDetails) {
+	dirs := cpuDirectories(fsys, cpuDir)
+	if len(dirs) > 0 && details.Threads == 0 {
+		details.Threads = len(dirs)
+	}
+
+	cores := make(map[string]bool)
+	for _, dir := range dirs {
+		topology := path.Join(dir, "topology")
+		coreID := readSysfsString(fsys, topology, "core_id")
+		if coreID == "" {
+			continue
+		}
+		cores[readSysfsString(fsys, topology, "physical_package_id")+":"+coreID] = true
+	}
+	if len(cores) > 0 {
+		details.Cores = len(cores)
+	}
+
+	if details.Cores == 0 {
+		details.Cores = details.Threads
+	}
+}
+
+// readCPUFrequency reads the highest current and maximum frequency over all CPUs
+func readCPUFrequency(fsys fs.FS, cpuDir string, details *CPUDetails) {
+	var current, maximum int64
+	for _, dir := range cpuDirectories(fsys, cpuDir) {
+		cpufreq := path.Join(dir, "cpufreq")
+		if khz, ok := readSysfsInt(fsys, cpufreq, "scaling_cur_freq"); ok && khz > current {
+			current = khz
+		}
+		if khz, ok := readSysfsInt(fsys, cpufreq, "cpuinfo_max_freq"); ok && khz > maximum {
+			maximum = khz
+		}
+	}
+
+	if current > 0 {
+		details.CurrentMHz = float64(current) / 1000
+	}
+	if maximum > 0 {
+		details.MaxMHz = float64(maximum) / 1000
+	}
+}
+
+// cpuSensors lists hwmon drivers reporting the CPU package temperature, with
+// the preferred sensor label for each
+var cpuSensors = map[string]string{
+	"coretemp":    "Package id 0",
+	"k10temp":     "Tctl",
+	"zenpower":    "Tdie",
+	"cpu_thermal": "",
+	"soc_thermal": "",
+}
+
+// cpuThermalZones lists thermal zone types that describe the CPU package
+var cpuThermalZones = map[string]bool{
+	"x86_pkg_temp": true,
+	"cpu-thermal":  true,
+	"cpu_thermal":  true,
+	"soc_thermal":  true,
+	"cpu0-thermal": true,
+}
+
+// readCPUTemperature returns the package temperature in °C, or 0 when no sensor is found
+func readCPUTemperature(fsys fs.FS) float64 {
+	hwmonDirs, _ := fs.Glob(fsys, path.Join(sysfsDir, "class", "hwmon", "hwmon*"))
+	for _, dir := range hwmonDirs {
+		label, ok := cpuSensors[readSysfsString(fsys, dir, "name")]
+		if !ok {
+			continue
+		}
+
+		input := "temp1_input"
+		if label != "" {
+			labels, _ := fs.Glob(fsys, path.Join(dir, "temp*_label"))
+			for _, name := range labels {
+				if readSysfsString(fsys, dir, path.Base(name)) == label {
+					input = strings.TrimSuffix(path.Base(name), "_label") + "_input"
+					break
+				}
+			}
+		}
+
+		if millidegrees, ok := readSysfsInt(fsys, dir, input); ok {
+			return float64(millidegrees) / 1000
+		}
+	}
+
+	zones, _ := fs.Glob(fsys, path.Join(sysfsDir, "class", "thermal", "thermal_zone*"))
+	for _, dir := range zones {
+		if !cpuThermalZones[readSysfsString(fsys, dir, "type")] {
+			continue
+		}
+		if millidegrees, ok := readSysfsInt(fsys, dir, "temp"); ok {
+			return float64(millidegrees) / 1000
+		}
+	}
+
+	return 0
+}
+
+// optionalInt renders n, or "" when it is unknown
+func optionalInt(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// optionalFloat renders f with format, or "" when it is unknown
+func optionalFloat(f float64, format string) string {
+	if f <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, f)
 }
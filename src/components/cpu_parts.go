@@ -0,0 +1,105 @@
+package components
+
+import (
+	"strconv"
+	"strings"
+)
+
+// armImplementers maps the "CPU implementer" field of /proc/cpuinfo to a vendor name
+var armImplementers = map[uint64]string{
+	0x41: "ARM",
+	0x42: "Broadcom",
+	0x43: "Cavium",
+	0x46: "Fujitsu",
+	0x48: "HiSilicon",
+	0x4e: "NVIDIA",
+	0x50: "APM",
+	0x51: "Qualcomm",
+	0x53: "Samsung",
+	0x56: "Marvell",
+	0x61: "Apple",
+	0x69: "Intel",
+	0xc0: "Ampere",
+}
+
+// armParts maps implementer and "CPU part" to a core name
+var armParts = map[uint64]map[uint64]string{
+	0x41: {
+		0xb76: "ARM1176",
+		0xc07: "Cortex-A7",
+		0xc08: "Cortex-A8",
+		0xc09: "Cortex-A9",
+		0xc0d: "Cortex-A12",
+		0xc0e: "Cortex-A17",
+		0xc0f: "Cortex-A15",
+		0xd01: "Cortex-A32",
+		0xd02: "Cortex-A34",
+		0xd03: "Cortex-A53",
+		0xd04: "Cortex-A35",
+		0xd05: "Cortex-A55",
+		0xd06: "Cortex-A65",
+		0xd07: "Cortex-A57",
+		0xd08: "Cortex-A72",
+		0xd09: "Cortex-A73",
+		0xd0a: "Cortex-A75",
+		0xd0b: "Cortex-A76",
+		0xd0c: "Neoverse-N1",
+		0xd0d: "Cortex-A77",
+		0xd0e: "Cortex-A76AE",
+		0xd40: "Neoverse-V1",
+		0xd41: "Cortex-A78",
+		0xd44: "Cortex-X1",
+		0xd46: "Cortex-A510",
+		0xd47: "Cortex-A710",
+		0xd48: "Cortex-X2",
+		0xd49: "Neoverse-N2",
+		0xd4b: "Cortex-A78C",
+		0xd4d: "Cortex-A715",
+		0xd4e: "Cortex-X3",
+		0xd4f: "Neoverse-V2",
+		0xd80: "Cortex-A520",
+		0xd81: "Cortex-A720",
+		0xd82: "Cortex-X4",
+	},
+	0x51: {
+		0x800: "Kryo 2xx Gold",
+		0x801: "Kryo 2xx Silver",
+		0x802: "Kryo 3xx Gold",
+		0x803: "Kryo 3xx Silver",
+		0x804: "Kryo 4xx Gold",
+		0x805: "Kryo 4xx Silver",
+		0xc00: "Falkor",
+	},
+	0x61: {
+		0x022: "Icestorm (M1)",
+		0x023: "Firestorm (M1)",
+		0x032: "Blizzard (M2)",
+		0x033: "Avalanche (M2)",
+	},
+}
+
+// armVendor returns the vendor name for a "CPU implementer" value such as "0x41"
+func armVendor(implementer string) string {
+	id, err := parseHex(implementer)
+	if err != nil {
+		return ""
+	}
+	return armImplementers[id]
+}
+
+// armPartName returns the core name for an implementer/part pair, or "" when unknown
+func armPartName(implementer, part string) string {
+	implementerID, err := parseHex(implementer)
+	if err != nil {
+		return ""
+	}
+	partID, err := parseHex(part)
+	if err != nil {
+		return ""
+	}
+	return armParts[implementerID][partID]
+}
+
+func parseHex(value string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(value), "0x"), 16, 64)
+}
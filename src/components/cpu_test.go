@@ -0,0 +1,146 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func newCPUInfo(t *testing.T, files map[string]string, outputs map[string]string, format string) *CPUInfo {
+	t.Helper()
+	info := &CPUInfo{SystemInfo: SystemInfo{FS: newFixtureFS(files), Exec: newFakeExecutor(outputs)}, Options: DefaultCPUOptions()}
+	if format != "" {
+		if err := info.Configure(json.RawMessage(fmt.Sprintf(`{"format": %q}`, format))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// intelSysfs describes four threads on two cores with cpufreq and coretemp
+func intelSysfs(t *testing.T) map[string]string {
+	files := map[string]string{
+		"proc/cpuinfo":                       testdata(t, "cpuinfo/x86"),
+		"sys/class/hwmon/hwmon0/name":        "acpitz\n",
+		"sys/class/hwmon/hwmon0/temp1_input": "27800\n",
+		"sys/class/hwmon/hwmon3/name":        "coretemp\n",
+		"sys/class/hwmon/hwmon3/temp1_label": "Package id 0\n",
+		"sys/class/hwmon/hwmon3/temp1_input": "46000\n",
+		"sys/class/hwmon/hwmon3/temp2_label": "Core 0\n",
+		"sys/class/hwmon/hwmon3/temp2_input": "44000\n",
+		"proc/sys/kernel/random/boot_id":     "5b5a0d3a-8c1b-4a34-9f57-6a4c1f0e2d11\n",
+	}
+	for cpu := 0; cpu < 4; cpu++ {
+		dir := path.Join("sys/devices/system/cpu", fmt.Sprintf("cpu%d", cpu))
+		files[path.Join(dir, "topology", "physical_package_id")] = "0\n"
+		files[path.Join(dir, "topology", "core_id")] = fmt.Sprintf("%d\n", cpu/2*4)
+		files[path.Join(dir, "cpufreq", "scaling_cur_freq")] = fmt.Sprintf("%d\n", 1400000+cpu*100000)
+		files[path.Join(dir, "cpufreq", "cpuinfo_max_freq")] = "4700000\n"
+	}
+	return files
+}
+
+func TestCPUInfoX86(t *testing.T) {
+	info := newCPUInfo(t, intelSysfs(t), nil, "{model} ({cores}C/{threads}T) @ {max_ghz} GHz, {cur_mhz} MHz, {temp}°C")
+	result := info.GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	want := "12th Gen Intel Core i7-1260P (2C/4T) @ 4.70 GHz, 1700 MHz, 46.0°C"
+	if result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+	if result.Fields["vendor"] != "Intel" {
+		t.Errorf("vendor = %v", result.Fields["vendor"])
+	}
+}
+
+func TestCPUInfoARM(t *testing.T) {
+	info := newCPUInfo(t, map[string]string{
+		"proc/cpuinfo":                         testdata(t, "cpuinfo/arm"),
+		"sys/class/thermal/thermal_zone0/type": "cpu-thermal\n",
+		"sys/class/thermal/thermal_zone0/temp": "51121\n",
+	}, nil, "{model} [{vendor}] {threads} threads {temp}°C")
+
+	result := info.GetInfo()
+	if want := "BCM2835 (Cortex-A72) [ARM] 2 threads 51.1°C"; result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+}
+
+func TestCPUInfoFallsBackToLscpu(t *testing.T) {
+	info := newCPUInfo(t, nil, map[string]string{"lscpu": testdata(t, "lscpu.txt")}, "")
+	if result := info.GetInfo(); result.Value != "AMD Ryzen 7 5800X 8-Core Processor" {
+		t.Errorf("Value = %q", result.Value)
+	}
+}
+
+func TestCPUInfoNotFound(t *testing.T) {
+	result := newCPUInfo(t, nil, nil, "").GetInfo()
+	if result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+}
+
+func TestCPUInfoCacheKey(t *testing.T) {
+	files := intelSysfs(t)
+
+	if key, ok := newCPUInfo(t, files, nil, "").CacheKey(); !ok || key != "5b5a0d3a-8c1b-4a34-9f57-6a4c1f0e2d11" {
+		t.Errorf("CacheKey() = %q, %v", key, ok)
+	}
+	for _, format := range []string{"{model} {cur_ghz}", "{model} {temp}"} {
+		if _, ok := newCPUInfo(t, files, nil, format).CacheKey(); ok {
+			t.Errorf("format %q should not be cached", format)
+		}
+	}
+}
+
+func TestCPUInfoLiveFields(t *testing.T) {
+	info := newCPUInfo(t, intelSysfs(t), nil, "")
+	result := info.GetInfo()
+
+	// Every field that changes while the system runs is read again on a
+	// cached run
+	live := info.LiveFields()
+	if len(live) != 2 || live["current_mhz"] != 1700.0 || live["temperature_c"] != 46.0 {
+		t.Errorf("LiveFields() = %v", live)
+	}
+	for name, value := range live {
+		if result.Fields[name] != value {
+			t.Errorf("%s = %v in GetInfo, %v live", name, result.Fields[name], value)
+		}
+	}
+}
+
+func TestCleanCPUModel(t *testing.T) {
+	tests := map[string]string{
+		"Intel(R) Core(TM) i5-8250U CPU @ 1.60GHz": "Intel Core i5-8250U CPU",
+		"AMD Ryzen 9 7950X 16-Core Processor":      "AMD Ryzen 9 7950X 16-Core Processor",
+		"  Intel(R)  Xeon(R) Gold 6248R  ":         "Intel Xeon Gold 6248R",
+	}
+	for model, want := range tests {
+		if got := cleanCPUModel(model); got != want {
+			t.Errorf("cleanCPUModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestParseCPUInfoFixtures(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "cpuinfo", "x86"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	details, err := parseCPUInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Threads != 4 || details.Cores != 2 || details.CurrentMHz != 3400.125 {
+		t.Errorf("details = %+v", details)
+	}
+}
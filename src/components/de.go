@@ -4,22 +4,33 @@ import (
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "de",
+		Label:   "Desktop",
+		Icon:    "󰧨",
+		Enabled: true,
+		Order:   180,
+		New:     func() InfoProvider { return &DEInfo{SystemInfo: SystemInfo{Name: "Desktop"}} },
+	})
+}
+
 // DEInfo provides desktop environment information
 type DEInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the desktop environment
-func (d *DEInfo) GetInfo() string {
+func (d *DEInfo) GetInfo() Result {
 	de := common.GetEnv("XDG_CURRENT_DESKTOP", "")
 	if de != "" {
-		return de
+		return NewResult(de, Fields{"name": de})
 	}
 
 	de = common.GetEnv("DESKTOP_SESSION", "")
 	if de != "" {
-		return de
+		return NewResult(de, Fields{"name": de})
 	}
 
-	return "Unknown"
+	return ErrorResult(ErrNotFound)
 }
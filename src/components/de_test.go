@@ -0,0 +1,22 @@
+package components
+
+import "testing"
+
+func TestDEInfo(t *testing.T) {
+	clearEnv(t, desktopEnv...)
+	info := &DEInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error without a session, got %q", result.Value)
+	}
+
+	t.Setenv("DESKTOP_SESSION", "plasma")
+	if value := info.GetInfo().Value; value != "plasma" {
+		t.Errorf("DESKTOP_SESSION: Value = %q", value)
+	}
+
+	t.Setenv("XDG_CURRENT_DESKTOP", "KDE")
+	if value := info.GetInfo().Value; value != "KDE" {
+		t.Errorf("XDG_CURRENT_DESKTOP: Value = %q", value)
+	}
+}
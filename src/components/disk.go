@@ -1,35 +1,283 @@
 package components
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
-
-	"lunarfetch/src/common"
+	"syscall"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "disk",
+		Label:   "Disk",
+		Icon:    "󰋊",
+		Enabled: true,
+		Order:   80,
+		New: func() InfoProvider {
+			return &DiskInfo{SystemInfo: SystemInfo{Name: "Disk"}}
+		},
+	})
+}
+
+// mountsPath is the kernel file mounted filesystems are read from
+var mountsPath = path.Join(procDir, "self", "mounts")
+
+// pseudoFilesystems are skipped unless DiskOptions.IncludePseudo is set
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "efivarfs": true,
+	"fuse.gvfsd-fuse": true, "fuse.lxcfs": true, "fuse.portal": true, "fuse.snapfuse": true,
+	"fusectl": true, "hugetlbfs": true, "mqueue": true, "nfsd": true, "nsfs": true,
+	"overlay": true, "proc": true, "pstore": true, "ramfs": true, "rootfs": true,
+	"rpc_pipefs": true, "securityfs": true, "selinuxfs": true, "squashfs": true,
+	"sysfs": true, "tmpfs": true, "tracefs": true,
+}
+
+// DiskOptions configures which mounts are reported
+type DiskOptions struct {
+	// Mountpoints lists the mounts to show, in order; empty means all real filesystems
+	Mountpoints []string `json:"mountpoints"`
+	// ExcludeTypes lists additional filesystem types to skip
+	ExcludeTypes []string `json:"excludeTypes"`
+	// IncludePseudo shows tmpfs, overlay and other virtual filesystems
+	IncludePseudo bool `json:"includePseudo"`
+}
+
+// Mount is a single entry of the mount table
+type Mount struct {
+	Device     string
+	Mountpoint string
+	FSType     string
+}
+
+// DiskUsage holds the space figures of a mounted filesystem in bytes
+type DiskUsage struct {
+	Total     uint64
+	Free      uint64
+	Available uint64
+}
+
 // DiskInfo provides disk usage information
 type DiskInfo struct {
 	SystemInfo
+	Options DiskOptions
+	// Statfs returns the usage of the filesystem mounted at a path
+	Statfs func(path string) (DiskUsage, error)
+}
+
+// Configure applies the "disk" options from config.json
+func (d *DiskInfo) Configure(options json.RawMessage) error {
+	var opts DiskOptions
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	d.Options = opts
+	return nil
 }
 
-// GetInfo returns the disk usage
-func (d *DiskInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("df", "-B1")
+// GetInfo returns the disk usage of each selected mount
+func (d *DiskInfo) GetInfo() Result {
+	file, err := d.rootFS().Open(mountsPath)
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
-	lines := strings.Split(out, "\n")
+	defer file.Close()
+
+	mounts, err := parseMounts(file)
+	if err != nil {
+		return ErrorResult(err)
+	}
+
+	statfs := d.Statfs
+	if statfs == nil {
+		statfs = statfsUsage
+	}
+
+	var lines []Line
+	var details []Fields
 	var totalUsed uint64
-	var totalSize uint64
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) > 3 && fields[0] != "Filesystem" {
-			used, _ := strconv.ParseUint(fields[2], 10, 64)
-			size, _ := strconv.ParseUint(fields[1], 10, 64)
-			totalUsed += used
-			totalSize += size
+	// Free space is counted once per pool: ZFS datasets each report the free
+	// space of the pool they share
+	free := make(map[string]uint64)
+	available := make(map[string]uint64)
+
+	for _, mount := range d.selectMounts(mounts) {
+		usage, err := statfs(mount.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+
+		used := usage.Total - usage.Free
+		percent := diskPercent(used, usage.Available)
+		totalUsed += used
+		pool := storagePool(mount)
+		free[pool] = max(free[pool], usage.Free)
+		available[pool] = max(available[pool], usage.Available)
+
+		lines = append(lines, Line{
+			Label: fmt.Sprintf("Disk (%s)", mount.Mountpoint),
+			Value: fmt.Sprintf("%s / %s (%.0f%%) - %s", FormatBytes(used), FormatBytes(usage.Total), percent, mount.FSType),
+		})
+		details = append(details, Fields{
+			"mountpoint":      mount.Mountpoint,
+			"device":          mount.Device,
+			"fs_type":         mount.FSType,
+			"used_bytes":      used,
+			"total_bytes":     usage.Total,
+			"available_bytes": usage.Available,
+			"percent":         percent,
+		})
+	}
+
+	if len(lines) == 0 {
+		return ErrorResult(ErrNotFound)
+	}
+
+	totalSize, totalAvailable := totalUsed, uint64(0)
+	for pool := range free {
+		totalSize += free[pool]
+		totalAvailable += available[pool]
+	}
+	percent := diskPercent(totalUsed, totalAvailable)
+	result := NewResult(fmt.Sprintf("%s / %s (%.0f%%)", FormatBytes(totalUsed), FormatBytes(totalSize), percent), Fields{
+		"mounts":          details,
+		"used_bytes":      totalUsed,
+		"total_bytes":     totalSize,
+		"available_bytes": totalAvailable,
+		"percent":         percent,
+	})
+	result.Lines = lines
+	result.Summary = true
+	return result
+}
+
+// selectMounts applies the configured mountpoints or, when none are set, drops
+// pseudo filesystems and keeps one mount per device
+func (d *DiskInfo) selectMounts(mounts []Mount) []Mount {
+	if len(d.Options.Mountpoints) > 0 {
+		byPath := make(map[string]Mount)
+		for _, mount := range mounts {
+			byPath[mount.Mountpoint] = mount
+		}
+
+		var selected []Mount
+		for _, mountpoint := range d.Options.Mountpoints {
+			if mount, ok := byPath[mountpoint]; ok {
+				selected = append(selected, mount)
+			}
+		}
+		return selected
+	}
+
+	excluded := make(map[string]bool)
+	for _, fsType := range d.Options.ExcludeTypes {
+		excluded[fsType] = true
+	}
+
+	// Shorter mountpoints first so bind mounts and subvolumes collapse onto
+	// the mount closest to the root
+	sorted := append([]Mount(nil), mounts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Mountpoint) < len(sorted[j].Mountpoint)
+	})
+
+	seen := make(map[string]bool)
+	var selected []Mount
+	for _, mount := range sorted {
+		if excluded[mount.FSType] {
+			continue
+		}
+		if !d.Options.IncludePseudo && pseudoFilesystems[mount.FSType] {
+			continue
+		}
+		if seen[mount.Device] {
+			continue
+		}
+		seen[mount.Device] = true
+		selected = append(selected, mount)
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Mountpoint < selected[j].Mountpoint
+	})
+	return selected
+}
+
+// storagePool names the storage a mount takes its free space from: the pool
+// of a ZFS dataset, which is the dataset name up to the first slash, or else
+// the device
+func storagePool(mount Mount) string {
+	if mount.FSType == "zfs" {
+		pool, _, _ := strings.Cut(mount.Device, "/")
+		return "zfs:" + pool
+	}
+	return mount.Device
+}
+
+// diskPercent computes the usage percentage the way df does, relative to the
+// space available to unprivileged users
+func diskPercent(used, available uint64) float64 {
+	return percentOf(used, used+available)
+}
+
+// statfsUsage queries the kernel for the usage of the filesystem at path
+func statfsUsage(path string) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskUsage{}, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	return DiskUsage{
+		Total:     uint64(stat.Blocks) * blockSize,
+		Free:      uint64(stat.Bfree) * blockSize,
+		Available: uint64(stat.Bavail) * blockSize,
+	}, nil
+}
+
+// parseMounts reads /proc/self/mounts formatted data
+func parseMounts(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+
+		mounts = append(mounts, Mount{
+			Device:     unescapeMountField(fields[0]),
+			Mountpoint: unescapeMountField(fields[1]),
+			FSType:     fields[2],
+		})
+	}
+
+	return mounts, scanner.Err()
+}
+
+// unescapeMountField decodes the octal escapes (\040 for space) used in the mount table
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, "\\") {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
 		}
+		b.WriteByte(field[i])
 	}
-	return fmt.Sprintf("%s / %s", FormatBytes(totalUsed), FormatBytes(totalSize))
+	return b.String()
 }
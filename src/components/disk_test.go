@@ -0,0 +1,137 @@
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dfStatfs serves filesystem usage from recorded `df -B1` output
+func dfStatfs(t *testing.T, name string) func(path string) (DiskUsage, error) {
+	t.Helper()
+	usage := make(map[string]DiskUsage)
+
+	lines := strings.Split(strings.TrimSpace(testdata(t, name)), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		numbers := make([]uint64, 3)
+		for i := range numbers {
+			n, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			numbers[i] = n
+		}
+		mountpoint := strings.Join(fields[5:], " ")
+		usage[mountpoint] = DiskUsage{Total: numbers[0], Free: numbers[0] - numbers[1], Available: numbers[2]}
+	}
+
+	return func(path string) (DiskUsage, error) {
+		if u, ok := usage[path]; ok {
+			return u, nil
+		}
+		return DiskUsage{}, fmt.Errorf("statfs %s: no such file or directory", path)
+	}
+}
+
+func newDiskInfo(t *testing.T, options string) *DiskInfo {
+	t.Helper()
+	return newDiskInfoFrom(t, "mounts.txt", "df.txt", options)
+}
+
+// newDiskInfoFrom reads the mount table and usage from the named fixtures
+func newDiskInfoFrom(t *testing.T, mounts, df, options string) *DiskInfo {
+	t.Helper()
+	fsys := newFixtureFS(map[string]string{"proc/self/mounts": testdata(t, mounts)})
+	info := &DiskInfo{SystemInfo: SystemInfo{FS: fsys, Exec: newFakeExecutor(nil)}, Statfs: dfStatfs(t, df)}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+func TestDiskInfo(t *testing.T) {
+	result := newDiskInfo(t, "").GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// /home shares its device with / and pseudo filesystems are skipped
+	want := []Line{
+		{"Disk (/)", "200.00 GB / 500.00 GB (40%) - btrfs"},
+		{"Disk (/boot)", "256.00 MB / 1.00 GB (25%) - vfat"},
+		{"Disk (/mnt/Media Library)", "1.46 TB / 1.95 TB (79%) - ext4"},
+	}
+	if len(result.Lines) != len(want) {
+		t.Fatalf("Lines = %v", result.Lines)
+	}
+	for i, line := range want {
+		if result.Lines[i] != line {
+			t.Errorf("line %d = %v, want %v", i, result.Lines[i], line)
+		}
+	}
+
+	// The value sums the mounts for formats that show it instead of the
+	// lines, with the percentage worked out as on each line
+	if want := "1.66 TB / 2.44 TB (71%)"; result.Value != want || !result.Summary {
+		t.Errorf("Value = %q (summary %v), want %q", result.Value, result.Summary, want)
+	}
+}
+
+func TestDiskInfoDatasetsAndNetwork(t *testing.T) {
+	result := newDiskInfoFrom(t, "mounts-zfs.txt", "df-zfs.txt", "").GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	// Mounts whose source is not a device path are real filesystems too
+	want := []Line{
+		{"Disk (/)", "40.00 GB / 400.00 GB (10%) - zfs"},
+		{"Disk (/home)", "100.00 GB / 460.00 GB (22%) - zfs"},
+		{"Disk (/mnt/nas)", "3.00 TB / 4.00 TB (75%) - nfs4"},
+		{"Disk (/mnt/share)", "500.00 GB / 1.00 TB (49%) - cifs"},
+	}
+	if len(result.Lines) != len(want) {
+		t.Fatalf("Lines = %v", result.Lines)
+	}
+	for i, line := range want {
+		if result.Lines[i] != line {
+			t.Errorf("line %d = %v, want %v", i, result.Lines[i], line)
+		}
+	}
+
+	// Both datasets report the free space of rpool, which is counted once
+	if want := "3.62 TB / 5.49 TB (66%)"; result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+}
+
+func TestDiskInfoOptions(t *testing.T) {
+	result := newDiskInfo(t, `{"mountpoints": ["/home", "/"]}`).GetInfo()
+	if len(result.Lines) != 2 || result.Lines[0].Label != "Disk (/home)" || result.Lines[1].Label != "Disk (/)" {
+		t.Errorf("mountpoints: Lines = %v", result.Lines)
+	}
+
+	result = newDiskInfo(t, `{"excludeTypes": ["vfat", "ext4"]}`).GetInfo()
+	if len(result.Lines) != 1 || result.Lines[0].Label != "Disk (/)" {
+		t.Errorf("excludeTypes: Lines = %v", result.Lines)
+	}
+}
+
+func TestUnescapeMountField(t *testing.T) {
+	tests := map[string]string{
+		`/mnt/Media\040Library`: "/mnt/Media Library",
+		`/mnt/tab\011here`:      "/mnt/tab\there",
+		`/mnt/plain`:            "/mnt/plain",
+		`/mnt/trailing\04`:      `/mnt/trailing\04`,
+	}
+	for field, want := range tests {
+		if got := unescapeMountField(field); got != want {
+			t.Errorf("unescapeMountField(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
@@ -1,28 +1,320 @@
 package components
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "gpu",
+		Label:    "GPU",
+		Icon:     "󰢮",
+		Enabled:  true,
+		Order:    120,
+		Priority: 70,
+		CacheTTL: 7 * 24 * time.Hour,
+		New: func() InfoProvider {
+			return &GPUInfo{SystemInfo: SystemInfo{Name: "GPU"}, PCIIDsPaths: DefaultPCIIDsPaths, Options: DefaultGPUOptions()}
+		},
+	})
+}
+
+// GPU types reported in GPU.Type
+const (
+	GPUTypeIntegrated = "Integrated"
+	GPUTypeDiscrete   = "Discrete"
+)
+
+// pciVendors gives short names for common GPU vendors so output does not
+// depend on the long pci.ids spelling ("Advanced Micro Devices, Inc. [AMD/ATI]")
+var pciVendors = map[string]string{
+	"1002": "AMD",
+	"10de": "NVIDIA",
+	"8086": "Intel",
+	"1af4": "Red Hat",
+	"1234": "QEMU",
+	"15ad": "VMware",
+	"80ee": "VirtualBox",
+	"1414": "Microsoft",
+	"1a03": "ASPEED",
+}
+
+// GPUOptions configures how GPUs are rendered
+type GPUOptions struct {
+	// ShowType appends [Integrated] or [Discrete]
+	ShowType bool `json:"showType"`
+	// ShowDriver appends the kernel driver in use
+	ShowDriver bool `json:"showDriver"`
+}
+
+// DefaultGPUOptions returns the options used when config.json sets none
+func DefaultGPUOptions() GPUOptions {
+	return GPUOptions{ShowType: true}
+}
+
+// GPU describes a single graphics device
+type GPU struct {
+	Name     string
+	Vendor   string
+	VendorID string
+	DeviceID string
+	Driver   string
+	Type     string
+	Slot     string
+}
+
 // GPUInfo provides GPU information
 type GPUInfo struct {
 	SystemInfo
+	// PCIIDsPaths are the pci.ids databases tried in order, relative to the root filesystem
+	PCIIDsPaths []string
+	Options     GPUOptions
+}
+
+// Configure applies the "gpu" options from config.json
+func (g *GPUInfo) Configure(options json.RawMessage) error {
+	opts := DefaultGPUOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	g.Options = opts
+	return nil
+}
+
+// CacheKey keys cached results to the boot and the DRM cards present, so
+// hot-plugged GPUs invalidate the cache
+func (g *GPUInfo) CacheKey() (string, bool) {
+	fsys := g.rootFS()
+	boot, ok := bootID(fsys)
+	if !ok {
+		return "", false
+	}
+	cards, _ := fs.Glob(fsys, path.Join(sysfsDir, "class", "drm", "card*"))
+	for i, card := range cards {
+		cards[i] = path.Base(card)
+	}
+	return boot + ":" + strings.Join(cards, ","), true
+}
+
+// GetInfo returns every GPU found on the PCI bus or through DRM
+func (g *GPUInfo) GetInfo() Result {
+	fsys := g.rootFS()
+
+	gpus := g.pciGPUs(fsys)
+	gpus = append(gpus, drmPlatformGPUs(fsys)...)
+	if len(gpus) == 0 {
+		gpus = lspciGPUs(g.executor())
+	}
+	if len(gpus) == 0 {
+		return ErrorResult(ErrNotFound)
+	}
+
+	details := make([]Fields, 0, len(gpus))
+	for _, gpu := range gpus {
+		details = append(details, Fields{
+			"name":      gpu.Name,
+			"vendor":    gpu.Vendor,
+			"vendor_id": gpu.VendorID,
+			"device_id": gpu.DeviceID,
+			"driver":    gpu.Driver,
+			"type":      gpu.Type,
+			"slot":      gpu.Slot,
+		})
+	}
+
+	result := NewResult("", Fields{"gpus": details, "model": gpus[0].Name})
+	if len(gpus) == 1 {
+		result.Value = g.formatGPU(gpus[0])
+		return result
+	}
+
+	for i, gpu := range gpus {
+		result.Lines = append(result.Lines, Line{
+			Label: fmt.Sprintf("GPU %d", i+1),
+			Value: g.formatGPU(gpu),
+		})
+	}
+	return result
+}
+
+// formatGPU renders a GPU as "NVIDIA GeForce RTX 3060 [Discrete]"
+func (g *GPUInfo) formatGPU(gpu GPU) string {
+	value := gpu.Name
+	if g.Options.ShowType && gpu.Type != "" {
+		value += fmt.Sprintf(" [%s]", gpu.Type)
+	}
+	if g.Options.ShowDriver && gpu.Driver != "" {
+		value += fmt.Sprintf(" (%s)", gpu.Driver)
+	}
+	return value
+}
+
+// pciGPUs lists display controllers (PCI class 0x03) on the PCI bus
+func (g *GPUInfo) pciGPUs(fsys fs.FS) []GPU {
+	devices, _ := fs.Glob(fsys, path.Join(sysfsDir, "bus", "pci", "devices", "*"))
+	sort.Strings(devices)
+
+	paths := g.PCIIDsPaths
+	if paths == nil {
+		paths = DefaultPCIIDsPaths
+	}
+
+	var gpus []GPU
+	for _, dir := range devices {
+		if !strings.HasPrefix(readSysfsString(fsys, dir, "class"), "0x03") {
+			continue
+		}
+
+		gpu := GPU{
+			VendorID: strings.TrimPrefix(readSysfsString(fsys, dir, "vendor"), "0x"),
+			DeviceID: strings.TrimPrefix(readSysfsString(fsys, dir, "device"), "0x"),
+			Driver:   linkBase(fsys, path.Join(dir, "driver")),
+			Slot:     path.Base(dir),
+		}
+		gpu.Vendor = pciVendors[gpu.VendorID]
+
+		vendorName, deviceName, found := lookupPCIName(fsys, paths, gpu.VendorID, gpu.DeviceID)
+		if gpu.Vendor == "" {
+			gpu.Vendor = vendorName
+		}
+		if found && deviceName != "" {
+			gpu.Name = strings.TrimSpace(gpu.Vendor + " " + marketingName(deviceName))
+		} else {
+			gpu.Name = strings.TrimSpace(fmt.Sprintf("%s GPU [%s:%s]", gpu.Vendor, gpu.VendorID, gpu.DeviceID))
+		}
+		gpu.Type = pciGPUType(fsys, dir, gpu)
+
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
+
+// drmPlatformGPUs lists DRM cards that are not PCI devices, such as the
+// GPUs built into ARM SoCs
+func drmPlatformGPUs(fsys fs.FS) []GPU {
+	cards, _ := fs.Glob(fsys, path.Join(sysfsDir, "class", "drm", "card*"))
+	sort.Strings(cards)
+
+	var gpus []GPU
+	for _, card := range cards {
+		// Connectors show up as card0-HDMI-A-1 next to the card itself
+		if strings.Contains(path.Base(card), "-") {
+			continue
+		}
+
+		device := path.Join(card, "device")
+		if linkBase(fsys, path.Join(device, "subsystem")) == "pci" {
+			continue
+		}
+
+		driver := linkBase(fsys, path.Join(device, "driver"))
+		if driver == "" {
+			continue
+		}
+
+		gpus = append(gpus, GPU{
+			Name:   platformGPUName(driver),
+			Driver: driver,
+			Type:   GPUTypeIntegrated,
+			Slot:   path.Base(card),
+		})
+	}
+	return gpus
 }
 
-// GetInfo returns the GPU model
-func (g *GPUInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("lspci")
+// platformGPUName maps SoC GPU drivers to a readable name
+func platformGPUName(driver string) string {
+	names := map[string]string{
+		"vc4-drm":   "Broadcom VideoCore",
+		"v3d":       "Broadcom V3D",
+		"panfrost":  "ARM Mali (Panfrost)",
+		"lima":      "ARM Mali (Lima)",
+		"msm":       "Qualcomm Adreno",
+		"msm_dpu":   "Qualcomm Adreno",
+		"etnaviv":   "Vivante GC",
+		"asahi":     "Apple GPU",
+		"apple-drm": "Apple GPU",
+		"tegra":     "NVIDIA Tegra",
+	}
+	if name, ok := names[driver]; ok {
+		return name
+	}
+	return driver
+}
+
+// pciGPUType guesses whether a PCI GPU is integrated into the CPU
+func pciGPUType(fsys fs.FS, dir string, gpu GPU) string {
+	switch gpu.VendorID {
+	case "8086":
+		// Arc discrete cards use the 0x56xx (Alchemist) and 0xe2xx (Battlemage) ranges
+		if strings.HasPrefix(gpu.DeviceID, "56") || strings.HasPrefix(gpu.DeviceID, "e2") {
+			return GPUTypeDiscrete
+		}
+		return GPUTypeIntegrated
+	case "10de":
+		return GPUTypeDiscrete
+	case "1002":
+		// amdgpu names the maker of dedicated memory chips, which APUs using
+		// system memory do not have. Their VRAM size is a firmware carve-out
+		// of any size and tells nothing.
+		if readSysfsString(fsys, dir, "mem_info_vram_vendor") != "" {
+			return GPUTypeDiscrete
+		}
+		// An APU sits on the SoC's internal fabric, without a PCIe link
+		if speed := readSysfsString(fsys, dir, "current_link_speed"); speed == "" || speed == "Unknown" {
+			return GPUTypeIntegrated
+		}
+	}
+	return ""
+}
+
+var bracketedName = regexp.MustCompile(`\[([^\]]+)\]`)
+
+// marketingName prefers the bracketed product name pci.ids gives after the
+// chip codename ("GA106 [GeForce RTX 3060]" becomes "GeForce RTX 3060")
+func marketingName(deviceName string) string {
+	if match := bracketedName.FindStringSubmatch(deviceName); match != nil {
+		return match[1]
+	}
+	return deviceName
+}
+
+var lspciRevision = regexp.MustCompile(`\s*\(rev [0-9a-f]+\)$`)
+
+// lspciGPUs parses lspci output, used when sysfs exposes no devices
+func lspciGPUs(executor common.Executor) []GPU {
+	out, err := executor.Execute("lspci")
 	if err != nil {
-		return "Unknown"
+		return nil
 	}
-	lines := strings.Split(out, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "VGA") || strings.Contains(line, "3D") {
-			fields := strings.Fields(line)
-			return strings.Join(fields[4:], " ")
+
+	var gpus []GPU
+	for _, line := range strings.Split(out, "\n") {
+		slot, rest, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		class, name, found := strings.Cut(rest, ": ")
+		if !found {
+			continue
 		}
+		if !strings.Contains(class, "VGA") && !strings.Contains(class, "3D") && !strings.Contains(class, "Display") {
+			continue
+		}
+
+		gpus = append(gpus, GPU{
+			Name: lspciRevision.ReplaceAllString(strings.TrimSpace(name), ""),
+			Slot: slot,
+		})
 	}
-	return "Unknown"
+	return gpus
 }
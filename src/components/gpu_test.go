@@ -0,0 +1,161 @@
+package components
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+	"testing"
+)
+
+const pciDevicesDir = "sys/bus/pci/devices"
+
+// addPCIDevice adds a PCI device with its class, IDs and bound driver
+func addPCIDevice(fsys fixtureFS, slot, class, vendor, device, driver string) {
+	dir := path.Join(pciDevicesDir, slot)
+	for name, value := range map[string]string{"class": class, "vendor": vendor, "device": device} {
+		fsys.MapFS[path.Join(dir, name)] = mapFile(value + "\n")
+	}
+	if driver != "" {
+		fsys.link(path.Join(dir, "driver"), "../../../bus/pci/drivers/"+driver)
+	}
+}
+
+func newGPUInfo(t *testing.T, fsys fixtureFS, outputs map[string]string, options string) *GPUInfo {
+	t.Helper()
+	info := &GPUInfo{SystemInfo: SystemInfo{FS: fsys, Exec: newFakeExecutor(outputs)}, Options: DefaultGPUOptions()}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// hybridLaptop has an Intel iGPU, an NVIDIA dGPU and a network controller
+func hybridLaptop(t *testing.T) fixtureFS {
+	fsys := newFixtureFS(map[string]string{
+		"usr/share/hwdata/pci.ids":       testdata(t, "pci.ids"),
+		"proc/sys/kernel/random/boot_id": "0d3f7c1e-2b4a-4e59-8c6d-7f1a2b3c4d5e\n",
+		"sys/class/drm/card0/dev":        "226:0\n",
+		"sys/class/drm/card1/dev":        "226:1\n",
+		"sys/class/drm/card1-eDP-1/dev":  "",
+	})
+	addPCIDevice(fsys, "0000:00:02.0", "0x030000", "0x8086", "0x46a6", "i915")
+	addPCIDevice(fsys, "0000:01:00.0", "0x030200", "0x10de", "0x2504", "nvidia")
+	addPCIDevice(fsys, "0000:02:00.0", "0x028000", "0x8086", "0x51f0", "iwlwifi")
+	fsys.link("sys/class/drm/card0/device/subsystem", "../../../bus/pci")
+	fsys.link("sys/class/drm/card1/device/subsystem", "../../../bus/pci")
+	return fsys
+}
+
+func TestGPUInfoHybrid(t *testing.T) {
+	result := newGPUInfo(t, hybridLaptop(t), nil, `{"showType": true, "showDriver": true}`).GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	want := []Line{
+		{"GPU 1", "Intel Iris Xe Graphics [Integrated] (i915)"},
+		{"GPU 2", "NVIDIA GeForce RTX 3060 Lite Hash Rate [Discrete] (nvidia)"},
+	}
+	if len(result.Lines) != len(want) {
+		t.Fatalf("Lines = %v", result.Lines)
+	}
+	for i, line := range want {
+		if result.Lines[i] != line {
+			t.Errorf("line %d = %v, want %v", i, result.Lines[i], line)
+		}
+	}
+}
+
+func TestGPUInfoAMDType(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  string
+	}{
+		{"discrete", map[string]string{
+			"mem_info_vram_total":  "17163091968",
+			"mem_info_vram_vendor": "samsung",
+			"current_link_speed":   "16.0 GT/s PCIe",
+		}, "[Discrete]"},
+		// An APU with an 8 GiB carve-out is still integrated
+		{"APU", map[string]string{
+			"mem_info_vram_total": "8589934592",
+			"current_link_speed":  "Unknown",
+		}, "[Integrated]"},
+		{"APU without link attributes", map[string]string{
+			"mem_info_vram_total": "536870912",
+		}, "[Integrated]"},
+		// A small card whose memory maker is not reported is left unnamed
+		{"unknown", map[string]string{
+			"mem_info_vram_total": "2147483648",
+			"current_link_speed":  "8.0 GT/s PCIe",
+		}, ""},
+	}
+	for _, tt := range tests {
+		fsys := newFixtureFS(map[string]string{"usr/share/misc/pci.ids": testdata(t, "pci.ids")})
+		addPCIDevice(fsys, "0000:c4:00.0", "0x030000", "0x1002", "0x73bf", "amdgpu")
+		for name, value := range tt.attrs {
+			fsys.MapFS[path.Join(pciDevicesDir, "0000:c4:00.0", name)] = mapFile(value + "\n")
+		}
+
+		result := newGPUInfo(t, fsys, nil, "").GetInfo()
+		want := strings.TrimSpace("AMD Radeon RX 6800/6800 XT / 6900 XT " + tt.want)
+		if result.Value != want {
+			t.Errorf("%s: Value = %q, want %q", tt.name, result.Value, want)
+		}
+	}
+}
+
+func TestGPUInfoUnknownDevice(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	addPCIDevice(fsys, "0000:00:01.0", "0x030000", "0x1234", "0x1111", "bochs-drm")
+
+	result := newGPUInfo(t, fsys, nil, `{"showType": false}`).GetInfo()
+	if want := "QEMU GPU [1234:1111]"; result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+}
+
+func TestGPUInfoPlatform(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{"sys/class/drm/card1/dev": "226:1\n"})
+	fsys.link("sys/class/drm/card1/device/subsystem", "../../../bus/platform")
+	fsys.link("sys/class/drm/card1/device/driver", "../../../bus/platform/drivers/vc4-drm")
+
+	result := newGPUInfo(t, fsys, nil, "").GetInfo()
+	if want := "Broadcom VideoCore [Integrated]"; result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+}
+
+func TestGPUInfoFallsBackToLspci(t *testing.T) {
+	outputs := map[string]string{"lspci": `00:00.0 Host bridge: Intel Corporation Device 4621 (rev 02)
+00:02.0 VGA compatible controller: Intel Corporation Alder Lake-P GT2 [Iris Xe Graphics] (rev 0c)
+01:00.0 3D controller: NVIDIA Corporation GA107M [GeForce RTX 3050 Mobile] (rev a1)`}
+
+	result := newGPUInfo(t, newFixtureFS(nil), outputs, "").GetInfo()
+	if len(result.Lines) != 2 || result.Lines[1].Value != "NVIDIA Corporation GA107M [GeForce RTX 3050 Mobile]" {
+		t.Errorf("Lines = %v", result.Lines)
+	}
+}
+
+func TestGPUInfoCacheKey(t *testing.T) {
+	key, ok := newGPUInfo(t, hybridLaptop(t), nil, "").CacheKey()
+	if want := "0d3f7c1e-2b4a-4e59-8c6d-7f1a2b3c4d5e:card0,card1,card1-eDP-1"; !ok || key != want {
+		t.Errorf("CacheKey() = %q, %v, want %q", key, ok, want)
+	}
+}
+
+func TestMarketingName(t *testing.T) {
+	tests := map[string]string{
+		"GA106 [GeForce RTX 3060]":            "GeForce RTX 3060",
+		"Alder Lake-P GT2 [Iris Xe Graphics]": "Iris Xe Graphics",
+		"SVGA II Adapter":                     "SVGA II Adapter",
+	}
+	for deviceName, want := range tests {
+		if got := marketingName(deviceName); got != want {
+			t.Errorf("marketingName(%q) = %q, want %q", deviceName, got, want)
+		}
+	}
+}
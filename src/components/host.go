@@ -3,25 +3,35 @@ package components
 import (
 	"os"
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "host",
+		Label:    "Host",
+		Icon:     "󰒋",
+		Enabled:  true,
+		Order:    10,
+		Priority: 90,
+		New:      func() InfoProvider { return &HostInfo{SystemInfo: SystemInfo{Name: "Host"}} },
+	})
+}
+
 // HostInfo provides hostname information
 type HostInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the hostname
-func (h *HostInfo) GetInfo() string {
+func (h *HostInfo) GetInfo() Result {
 	hostname, err := os.Hostname()
 	if err != nil {
 		// Try using command execution as fallback
-		out, err := common.GlobalCommandExecutor.Execute("hostname")
+		out, err := h.executor().Execute("hostname")
 		if err != nil {
-			return "Unknown"
+			return ErrorResult(err)
 		}
-		return strings.TrimSpace(out)
+		hostname = strings.TrimSpace(out)
 	}
-	return hostname
+	return NewResult(hostname, Fields{"hostname": hostname})
 }
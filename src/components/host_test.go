@@ -0,0 +1,17 @@
+package components
+
+import (
+	"os"
+	"testing"
+)
+
+func TestHostInfo(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skip(err)
+	}
+	info := &HostInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if value := info.GetInfo().Value; value != hostname {
+		t.Errorf("Value = %q, want %q", value, hostname)
+	}
+}
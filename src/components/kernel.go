@@ -2,20 +2,31 @@ package components
 
 import (
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "kernel",
+		Label:    "Kernel",
+		Icon:     "󰣇",
+		Enabled:  true,
+		Order:    40,
+		Priority: 70,
+		New:      func() InfoProvider { return &KernelInfo{SystemInfo: SystemInfo{Name: "Kernel"}} },
+	})
+}
+
 // KernelInfo provides kernel version information
 type KernelInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the kernel version
-func (k *KernelInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("uname", "-r")
+func (k *KernelInfo) GetInfo() Result {
+	out, err := k.executor().Execute("uname", "-r")
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
-	return strings.TrimSpace(out)
+	release := strings.TrimSpace(out)
+	return NewResult(release, Fields{"release": release})
 }
@@ -0,0 +1,29 @@
+package components
+
+import (
+	"errors"
+	"testing"
+
+	"lunarfetch/src/common"
+)
+
+func TestKernelInfo(t *testing.T) {
+	executor := newFakeExecutor(map[string]string{"uname -r": "6.10.6-arch1-1\n"})
+	info := &KernelInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: executor}}
+
+	result := info.GetInfo()
+	if result.Value != "6.10.6-arch1-1" || result.Fields["release"] != "6.10.6-arch1-1" {
+		t.Errorf("got %q, %v", result.Value, result.Fields)
+	}
+}
+
+func TestKernelInfoTimeout(t *testing.T) {
+	executor := newFakeExecutor(nil)
+	executor.errors["uname -r"] = common.ErrCommandTimeout
+	info := &KernelInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: executor}}
+
+	result := info.GetInfo()
+	if result.Value != "timeout" || !errors.Is(result.Err, common.ErrCommandTimeout) {
+		t.Errorf("got %q, %v", result.Value, result.Err)
+	}
+}
@@ -1,26 +1,199 @@
 package components
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"path"
+	"strconv"
 	"strings"
+)
+
+func init() {
+	Register(Registration{
+		Key:      "memory",
+		Label:    "Memory",
+		Icon:     "󰍛",
+		Enabled:  true,
+		Order:    90,
+		Priority: 70,
+		New: func() InfoProvider {
+			return &MemoryInfo{SystemInfo: SystemInfo{Name: "Memory"}, Options: DefaultMemoryOptions()}
+		},
+	})
+}
 
-	"lunarfetch/src/common"
+// meminfoPath is the kernel file memory statistics are read from
+var meminfoPath = path.Join(procDir, "meminfo")
+
+// Memory units accepted by MemoryOptions.Unit
+const (
+	MemoryUnitMiB  = "MiB"
+	MemoryUnitGiB  = "GiB"
+	MemoryUnitAuto = "auto"
 )
 
+// MemoryOptions configures how memory usage is rendered
+type MemoryOptions struct {
+	// Unit is one of "MiB", "GiB" or "auto"
+	Unit string `json:"unit"`
+	// Percent appends the used percentage to the value
+	Percent bool `json:"percent"`
+	// Breakdown lists extra lines to show: "swap", "buffers" and "cache"
+	Breakdown []string `json:"breakdown"`
+}
+
+// DefaultMemoryOptions returns the options used when config.json sets none
+func DefaultMemoryOptions() MemoryOptions {
+	return MemoryOptions{Unit: MemoryUnitMiB}
+}
+
 // MemoryInfo provides memory usage information
 type MemoryInfo struct {
 	SystemInfo
+	Options MemoryOptions
+}
+
+// Configure applies the "memory" options from config.json
+func (m *MemoryInfo) Configure(options json.RawMessage) error {
+	opts := DefaultMemoryOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(opts.Unit) {
+	case "", "mib":
+		opts.Unit = MemoryUnitMiB
+	case "gib":
+		opts.Unit = MemoryUnitGiB
+	case "auto":
+		opts.Unit = MemoryUnitAuto
+	default:
+		return fmt.Errorf("unknown memory unit %q", opts.Unit)
+	}
+
+	m.Options = opts
+	return nil
 }
 
 // GetInfo returns the memory usage
-func (m *MemoryInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("free", "-m")
+func (m *MemoryInfo) GetInfo() Result {
+	file, err := m.rootFS().Open(meminfoPath)
+	if err != nil {
+		return ErrorResult(err)
+	}
+	defer file.Close()
+
+	meminfo, err := parseMeminfo(file)
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
-	lines := strings.Split(out, "\n")
-	fields := strings.Fields(lines[1])
-	used := fields[2]
-	total := fields[1]
-	return fmt.Sprintf("%sMiB / %sMiB", used, total)
+
+	total, ok := meminfo["MemTotal"]
+	if !ok || total == 0 {
+		return ErrorResult(fmt.Errorf("MemTotal missing from %s", meminfoPath))
+	}
+
+	// Match free(1): cache includes reclaimable slab, and kernels older than
+	// 3.14 have no MemAvailable so it is estimated from free and cache.
+	cached := meminfo["Cached"] + meminfo["SReclaimable"]
+	available, ok := meminfo["MemAvailable"]
+	if !ok {
+		available = meminfo["MemFree"] + meminfo["Buffers"] + cached
+	}
+	if available > total {
+		available = total
+	}
+
+	used := total - available
+	swapTotal := meminfo["SwapTotal"]
+	swapUsed := swapTotal - min(meminfo["SwapFree"], swapTotal)
+
+	fields := Fields{
+		"used_bytes":       used,
+		"total_bytes":      total,
+		"available_bytes":  available,
+		"free_bytes":       meminfo["MemFree"],
+		"buffers_bytes":    meminfo["Buffers"],
+		"cached_bytes":     cached,
+		"swap_used_bytes":  swapUsed,
+		"swap_total_bytes": swapTotal,
+		"percent":          percentOf(used, total),
+	}
+
+	value := m.formatUsage(used, total)
+	if m.Options.Percent {
+		value = fmt.Sprintf("%s (%.0f%%)", value, percentOf(used, total))
+	}
+
+	result := NewResult(value, fields)
+	for _, item := range m.Options.Breakdown {
+		switch strings.ToLower(item) {
+		case "swap":
+			line := m.formatUsage(swapUsed, swapTotal)
+			if m.Options.Percent && swapTotal > 0 {
+				line = fmt.Sprintf("%s (%.0f%%)", line, percentOf(swapUsed, swapTotal))
+			}
+			result.Lines = append(result.Lines, Line{Label: "Swap", Value: line})
+		case "buffers":
+			result.Lines = append(result.Lines, Line{Label: "Buffers", Value: m.formatAmount(meminfo["Buffers"], total)})
+		case "cache", "cached":
+			result.Lines = append(result.Lines, Line{Label: "Cache", Value: m.formatAmount(cached, total)})
+		}
+	}
+
+	return result
+}
+
+// formatUsage renders "used / total" in the configured unit
+func (m *MemoryInfo) formatUsage(used, total uint64) string {
+	return fmt.Sprintf("%s / %s", m.formatAmount(used, total), m.formatAmount(total, total))
+}
+
+// formatAmount renders a byte count in the configured unit; reference decides
+// the unit in auto mode so both sides of "used / total" share it
+func (m *MemoryInfo) formatAmount(bytes, reference uint64) string {
+	unit := m.Options.Unit
+	if unit == MemoryUnitAuto {
+		unit = MemoryUnitMiB
+		if reference >= 1<<30 {
+			unit = MemoryUnitGiB
+		}
+	}
+
+	if unit == MemoryUnitGiB {
+		return fmt.Sprintf("%.2fGiB", float64(bytes)/(1<<30))
+	}
+	return fmt.Sprintf("%dMiB", bytes>>20)
+}
+
+// parseMeminfo reads /proc/meminfo formatted data into a map of byte counts
+func parseMeminfo(r io.Reader) (map[string]uint64, error) {
+	meminfo := make(map[string]uint64)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "kB") {
+			value *= 1024
+		}
+
+		meminfo[strings.TrimSpace(name)] = value
+	}
+
+	return meminfo, scanner.Err()
 }
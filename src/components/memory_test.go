@@ -0,0 +1,101 @@
+package components
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newMemoryInfo(t *testing.T, fixture, options string) *MemoryInfo {
+	t.Helper()
+	fsys := newFixtureFS(map[string]string{"proc/meminfo": testdata(t, "meminfo/"+fixture)})
+	info := &MemoryInfo{SystemInfo: SystemInfo{FS: fsys, Exec: newFakeExecutor(nil)}, Options: DefaultMemoryOptions()}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// freeColumns returns the MiB columns of a row of recorded `free -m` output
+func freeColumns(t *testing.T, row string) []int {
+	t.Helper()
+	for _, line := range strings.Split(testdata(t, "free.txt"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != row+":" {
+			continue
+		}
+		var columns []int
+		for _, field := range fields[1:] {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			columns = append(columns, n)
+		}
+		return columns
+	}
+	t.Fatalf("free.txt has no %s row", row)
+	return nil
+}
+
+func TestMemoryInfoMatchesFree(t *testing.T) {
+	mem := freeColumns(t, "Mem")
+	swap := freeColumns(t, "Swap")
+
+	result := newMemoryInfo(t, "modern", `{"breakdown": ["swap"]}`).GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	want := strconv.Itoa(mem[1]) + "MiB / " + strconv.Itoa(mem[0]) + "MiB"
+	if result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+	if len(result.Lines) != 1 || result.Lines[0].Label != "Swap" {
+		t.Fatalf("Lines = %v", result.Lines)
+	}
+	wantSwap := strconv.Itoa(swap[1]) + "MiB / " + strconv.Itoa(swap[0]) + "MiB"
+	if result.Lines[0].Value != wantSwap {
+		t.Errorf("Swap = %q, want %q", result.Lines[0].Value, wantSwap)
+	}
+	if cached := result.Fields["cached_bytes"].(uint64) + result.Fields["buffers_bytes"].(uint64); int(cached>>20) != mem[4] {
+		t.Errorf("buff/cache = %dMiB, want %dMiB", cached>>20, mem[4])
+	}
+}
+
+func TestMemoryInfoUnits(t *testing.T) {
+	tests := []struct {
+		options string
+		want    string
+	}{
+		{`{"unit": "GiB"}`, "6.08GiB / 15.50GiB"},
+		{`{"unit": "auto", "percent": true}`, "6.08GiB / 15.50GiB (39%)"},
+	}
+	for _, test := range tests {
+		if value := newMemoryInfo(t, "modern", test.options).GetInfo().Value; value != test.want {
+			t.Errorf("%s: Value = %q, want %q", test.options, value, test.want)
+		}
+	}
+
+	if err := (&MemoryInfo{}).Configure(json.RawMessage(`{"unit": "KiB"}`)); err == nil {
+		t.Error("Configure accepted an unknown unit")
+	}
+}
+
+func TestMemoryInfoEstimatesAvailableOnOldKernels(t *testing.T) {
+	// No MemAvailable: free + buffers + cache = 350MiB of 993MiB
+	result := newMemoryInfo(t, "legacy", `{"unit": "auto"}`).GetInfo()
+	if result.Value != "643MiB / 993MiB" {
+		t.Errorf("Value = %q", result.Value)
+	}
+}
+
+func TestMemoryInfoMissingMeminfo(t *testing.T) {
+	info := &MemoryInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if result := info.GetInfo(); result.Err == nil || result.Value != "Unknown" {
+		t.Errorf("got %q, %v", result.Value, result.Err)
+	}
+}
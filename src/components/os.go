@@ -1,25 +1,237 @@
 package components
 
 import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+)
+
+func init() {
+	Register(Registration{
+		Key:      "os",
+		Label:    "OS",
+		Icon:     "󰣇",
+		Enabled:  true,
+		Order:    30,
+		Priority: 90,
+		New: func() InfoProvider {
+			return &OSInfo{SystemInfo: SystemInfo{Name: "OS"}, Options: DefaultOSOptions()}
+		},
+	})
+}
+
+// osReleasePaths are tried in order, as specified by os-release(5)
+var osReleasePaths = []string{
+	path.Join("etc", "os-release"),
+	path.Join("usr", "lib", "os-release"),
+}
+
+// OSRelease holds the fields of an os-release file
+type OSRelease struct {
+	Name            string
+	PrettyName      string
+	ID              string
+	IDLike          []string
+	Version         string
+	VersionID       string
+	VersionCodename string
+	BuildID         string
+	Variant         string
+	VariantID       string
+}
 
-	"lunarfetch/src/common"
+// Is reports whether the distribution is id or derived from it (ID_LIKE)
+func (r OSRelease) Is(id string) bool {
+	if r.ID == id {
+		return true
+	}
+	for _, like := range r.IDLike {
+		if like == id {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	hostRelease     OSRelease
+	hostReleaseErr  error
+	hostReleaseOnce sync.Once
 )
 
+// HostOSRelease returns the os-release of the running system, read once
+func HostOSRelease() (OSRelease, error) {
+	hostReleaseOnce.Do(func() {
+		hostRelease, hostReleaseErr = ReadOSRelease(HostFS)
+	})
+	return hostRelease, hostReleaseErr
+}
+
+// ReadOSRelease parses /etc/os-release, or /usr/lib/os-release when the
+// former is missing, from the root filesystem fsys
+func ReadOSRelease(fsys fs.FS) (OSRelease, error) {
+	var lastErr error
+	for _, path := range osReleasePaths {
+		file, err := fsys.Open(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		defer file.Close()
+		return parseOSRelease(file)
+	}
+	return OSRelease{}, lastErr
+}
+
+// parseOSRelease reads KEY=value lines with shell-style quoting
+func parseOSRelease(r io.Reader) (OSRelease, error) {
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		values[strings.TrimSpace(key)] = unquoteOSReleaseValue(strings.TrimSpace(value))
+	}
+
+	release := OSRelease{
+		Name:            values["NAME"],
+		PrettyName:      values["PRETTY_NAME"],
+		ID:              values["ID"],
+		IDLike:          strings.Fields(values["ID_LIKE"]),
+		Version:         values["VERSION"],
+		VersionID:       values["VERSION_ID"],
+		VersionCodename: values["VERSION_CODENAME"],
+		BuildID:         values["BUILD_ID"],
+		Variant:         values["VARIANT"],
+		VariantID:       values["VARIANT_ID"],
+	}
+
+	// Defaults from os-release(5)
+	if release.Name == "" {
+		release.Name = "Linux"
+	}
+	if release.ID == "" {
+		release.ID = "linux"
+	}
+	if release.PrettyName == "" {
+		release.PrettyName = strings.TrimSpace(release.Name + " " + release.Version)
+	}
+
+	return release, scanner.Err()
+}
+
+func unquoteOSReleaseValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// OSOptions configures how the operating system is rendered
+type OSOptions struct {
+	// Format is a template using {name}, {pretty_name}, {id}, {id_like},
+	// {version}, {version_id}, {codename}, {build_id}, {variant} and {arch}
+	Format string `json:"format"`
+}
+
+// DefaultOSOptions returns the options used when config.json sets none
+func DefaultOSOptions() OSOptions {
+	return OSOptions{Format: "{pretty_name} {arch}"}
+}
+
+// goarchNames maps Go architecture names to the ones uname -m prints
+var goarchNames = map[string]string{
+	"amd64": "x86_64",
+	"386":   "i686",
+	"arm64": "aarch64",
+}
+
+// machineArch returns the machine architecture as uname -m prints it, read
+// from the kernel or else taken from the architecture lunarfetch was built for
+func machineArch(fsys fs.FS) string {
+	if arch := readSysfsString(fsys, path.Join(procDir, "sys", "kernel"), "arch"); arch != "" {
+		return arch
+	}
+	if arch, ok := goarchNames[runtime.GOARCH]; ok {
+		return arch
+	}
+	return runtime.GOARCH
+}
+
 // OSInfo provides operating system information
 type OSInfo struct {
 	SystemInfo
+	Options OSOptions
+}
+
+// Configure applies the "os" options from config.json
+func (o *OSInfo) Configure(options json.RawMessage) error {
+	opts := DefaultOSOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	if opts.Format == "" {
+		opts.Format = DefaultOSOptions().Format
+	}
+	o.Options = opts
+	return nil
 }
 
 // GetInfo returns the operating system information
-func (o *OSInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("lsb_release", "-si")
+func (o *OSInfo) GetInfo() Result {
+	fsys := o.rootFS()
+	release, err := ReadOSRelease(fsys)
 	if err != nil {
-		out, err = common.GlobalCommandExecutor.Execute("grep", "^NAME=", "/etc/os-release")
-		if err != nil {
-			return "Unknown"
-		}
-		return strings.Trim(strings.TrimPrefix(strings.TrimSpace(out), "NAME=\""), "\"")
+		return ErrorResult(err)
 	}
-	return strings.TrimSpace(out)
+	arch := machineArch(fsys)
+
+	values := map[string]string{
+		"name":        release.Name,
+		"pretty_name": release.PrettyName,
+		"id":          release.ID,
+		"id_like":     strings.Join(release.IDLike, " "),
+		"version":     release.Version,
+		"version_id":  release.VersionID,
+		"codename":    release.VersionCodename,
+		"build_id":    release.BuildID,
+		"variant":     release.Variant,
+		"arch":        arch,
+	}
+
+	// Collapse the gaps left by placeholders without a value
+	value := strings.Join(strings.Fields(ExpandTemplate(o.Options.Format, values)), " ")
+
+	return NewResult(value, Fields{
+		"name":        release.Name,
+		"pretty_name": release.PrettyName,
+		"id":          release.ID,
+		"id_like":     release.IDLike,
+		"version":     release.Version,
+		"version_id":  release.VersionID,
+		"codename":    release.VersionCodename,
+		"build_id":    release.BuildID,
+		"variant":     release.Variant,
+		"variant_id":  release.VariantID,
+		"arch":        arch,
+	})
 }
@@ -0,0 +1,125 @@
+package components
+
+import (
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestReadOSRelease(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    OSRelease
+	}{
+		{"arch", OSRelease{
+			Name: "Arch Linux", PrettyName: "Arch Linux", ID: "arch", IDLike: []string{}, BuildID: "rolling",
+		}},
+		{"ubuntu", OSRelease{
+			Name: "Ubuntu", PrettyName: "Ubuntu 24.04.1 LTS", ID: "ubuntu", IDLike: []string{"debian"},
+			Version: "24.04.1 LTS (Noble Numbat)", VersionID: "24.04", VersionCodename: "noble",
+		}},
+		{"fedora", OSRelease{
+			Name: "Fedora Linux", PrettyName: "Fedora Linux 40 (Workstation Edition)", ID: "fedora", IDLike: []string{},
+			Version: "40 (Workstation Edition)", VersionID: "40",
+			Variant: "Workstation Edition", VariantID: "workstation",
+		}},
+		{"quoting", OSRelease{
+			Name: "Luna OS", PrettyName: `Luna OS 1.0 "Crescent"`, ID: "luna", IDLike: []string{"arch", "manjaro"},
+			Version: `1.0 "Crescent"`, BuildID: "2024.06",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			fsys := newFixtureFS(map[string]string{
+				"etc/os-release": testdata(t, "os-release/"+test.fixture),
+			})
+			release, err := ReadOSRelease(fsys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(release, test.want) {
+				t.Errorf("got %+v\nwant %+v", release, test.want)
+			}
+		})
+	}
+}
+
+func TestReadOSReleaseFallsBackToUsrLib(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"usr/lib/os-release": testdata(t, "os-release/arch"),
+	})
+	release, err := ReadOSRelease(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.ID != "arch" {
+		t.Errorf("ID = %q, want arch", release.ID)
+	}
+}
+
+func TestOSReleaseIs(t *testing.T) {
+	release := OSRelease{ID: "ubuntu", IDLike: []string{"debian"}}
+	if !release.Is("ubuntu") || !release.Is("debian") {
+		t.Error("ubuntu should match its ID and ID_LIKE")
+	}
+	if release.Is("arch") {
+		t.Error("ubuntu should not match arch")
+	}
+}
+
+func TestOSInfo(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"etc/os-release":       testdata(t, "os-release/ubuntu"),
+		"proc/sys/kernel/arch": "x86_64\n",
+	})
+	executor := newFakeExecutor(nil)
+
+	info := &OSInfo{SystemInfo: SystemInfo{FS: fsys, Exec: executor}, Options: DefaultOSOptions()}
+	result := info.GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if result.Value != "Ubuntu 24.04.1 LTS x86_64" {
+		t.Errorf("Value = %q", result.Value)
+	}
+	if result.Fields["codename"] != "noble" || result.Fields["arch"] != "x86_64" {
+		t.Errorf("Fields = %v", result.Fields)
+	}
+
+	if err := info.Configure(json.RawMessage(`{"format": "{name} {version_id} ({codename})"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if value := info.GetInfo().Value; value != "Ubuntu 24.04 (noble)" {
+		t.Errorf("formatted Value = %q", value)
+	}
+}
+
+func TestOSInfoWithoutOSRelease(t *testing.T) {
+	executor := newFakeExecutor(nil)
+
+	info := &OSInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: executor}, Options: DefaultOSOptions()}
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+	if len(executor.calls) != 0 {
+		t.Errorf("commands run: %v", executor.calls)
+	}
+}
+
+func TestMachineArch(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{"proc/sys/kernel/arch": "aarch64\n"})
+	if arch := machineArch(fsys); arch != "aarch64" {
+		t.Errorf("machineArch() = %q, want aarch64", arch)
+	}
+
+	// Without procfs the build architecture is named as uname names it
+	want, ok := goarchNames[runtime.GOARCH]
+	if !ok {
+		want = runtime.GOARCH
+	}
+	if arch := machineArch(newFixtureFS(nil)); arch != want {
+		t.Errorf("machineArch() = %q, want %q", arch, want)
+	}
+}
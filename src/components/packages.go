@@ -1,57 +1,402 @@
 package components
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"io/fs"
+	"os"
+	"path"
 	"strings"
+	"time"
 
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "packages",
+		Label:    "Packages",
+		Icon:     "󰏗",
+		Enabled:  true,
+		Order:    100,
+		CacheTTL: 7 * 24 * time.Hour,
+		New: func() InfoProvider {
+			return &PackagesInfo{SystemInfo: SystemInfo{Name: "Packages"}}
+		},
+	})
+}
+
+// PackageManager counts the packages installed by one package manager
+type PackageManager struct {
+	Name string
+	// Count returns the number of installed packages and whether the manager
+	// was found; home is the user's home directory within fsys
+	Count func(fsys fs.FS, executor common.Executor, home string) (int, bool)
+}
+
+// PackageManagers lists the supported managers in display order
+var PackageManagers = []PackageManager{
+	{"pacman", countPacman},
+	{"dpkg", countDpkg},
+	{"rpm", countRpm},
+	{"apk", countApk},
+	{"portage", countPortage},
+	{"nix", countNix},
+	{"flatpak", countFlatpak},
+	{"snap", countSnap},
+	{"brew", countBrew},
+	{"pip", countPip},
+	{"cargo", countCargo},
+}
+
+// PackagesOptions configures which package managers are counted
+type PackagesOptions struct {
+	// Managers restricts counting to the named managers; empty counts all of them
+	Managers []string `json:"managers"`
+}
+
 // PackagesInfo provides package count information
 type PackagesInfo struct {
 	SystemInfo
+	// Home is the user's home directory for per-user installs; empty uses $HOME
+	Home    string
+	Options PackagesOptions
+}
+
+// Configure applies the "packages" options from config.json
+func (p *PackagesInfo) Configure(options json.RawMessage) error {
+	var opts PackagesOptions
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	p.Options = opts
+	return nil
+}
+
+// CacheKey changes whenever one of the package databases is modified or a
+// profile link is pointed at a new generation. Nix profiles resolve to store
+// paths, which all have the same modification time, so their link targets
+// are part of the key.
+func (p *PackagesInfo) CacheKey() (string, bool) {
+	fsys := p.rootFS()
+
+	var stamps []string
+	for _, name := range packageDatabases(fsys, homePath(p.Home)) {
+		stamp := name
+		if target, err := readLink(fsys, name); err == nil {
+			stamp += "->" + target
+		}
+		if info, err := fs.Stat(fsys, name); err == nil {
+			stamp += fmt.Sprintf("@%d", info.ModTime().UnixNano())
+		} else if stamp == name {
+			continue
+		}
+		stamps = append(stamps, stamp)
+	}
+	return strings.Join(stamps, ","), true
+}
+
+// packageDatabases lists the files and directories that change when
+// packages are installed or removed
+func packageDatabases(fsys fs.FS, home string) []string {
+	sitePackages, _ := fs.Glob(fsys, path.Join(home, ".local", "lib", "python3*", "site-packages"))
+
+	paths := []string{
+		path.Join("var", "lib", "pacman", "local"),
+		path.Join("var", "lib", "dpkg", "status"),
+		path.Join("var", "lib", "rpm", "rpmdb.sqlite"),
+		path.Join("var", "lib", "rpm", "Packages"),
+		path.Join("lib", "apk", "db", "installed"),
+		path.Join("var", "db", "pkg"),
+		path.Join("nix", "var", "nix", "profiles"),
+		path.Join("nix", "var", "nix", "profiles", "default"),
+		path.Join("run", "current-system"),
+		path.Join(home, ".nix-profile"),
+		path.Join(home, ".local", "state", "nix", "profiles"),
+		path.Join(home, ".local", "state", "nix", "profiles", "profile"),
+		path.Join("var", "lib", "flatpak", "app"),
+		path.Join("var", "lib", "flatpak", "runtime"),
+		path.Join(home, ".local", "share", "flatpak", "app"),
+		path.Join(home, ".local", "share", "flatpak", "runtime"),
+		path.Join("snap"),
+		path.Join("home", "linuxbrew", ".linuxbrew", "Cellar"),
+		path.Join(home, ".linuxbrew", "Cellar"),
+		path.Join("opt", "homebrew", "Cellar"),
+		path.Join("usr", "local", "Cellar"),
+		path.Join(cargoHomePath(home), ".crates.toml"),
+	}
+	return append(paths, sitePackages...)
+}
+
+// GetInfo returns the number of installed packages per package manager
+func (p *PackagesInfo) GetInfo() Result {
+	fsys, executor, home := p.rootFS(), p.executor(), homePath(p.Home)
+
+	wanted := make(map[string]bool)
+	for _, name := range p.Options.Managers {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	var parts []string
+	counts := Fields{}
+	total := 0
+
+	for _, manager := range PackageManagers {
+		if len(wanted) > 0 && !wanted[manager.Name] {
+			continue
+		}
+
+		count, found := manager.Count(fsys, executor, home)
+		if !found || count == 0 {
+			continue
+		}
+
+		counts[manager.Name] = count
+		total += count
+		parts = append(parts, fmt.Sprintf("%d (%s)", count, manager.Name))
+	}
+
+	if len(parts) == 0 {
+		return ErrorResult(ErrNotFound)
+	}
+
+	return NewResult(strings.Join(parts, ", "), Fields{"count": total, "managers": counts})
+}
+
+// countEntries counts the entries of dir accepted by keep, or all of them when keep is nil
+func countEntries(fsys fs.FS, dir string, keep func(fs.DirEntry) bool) (int, bool) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return 0, false
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if keep == nil || keep(entry) {
+			count++
+		}
+	}
+	return count, true
+}
+
+// countLines counts the lines of path accepted by match
+func countLines(fsys fs.FS, path string, match func(string) bool) (int, bool) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if match(scanner.Text()) {
+			count++
+		}
+	}
+	return count, scanner.Err() == nil
+}
+
+// sumCounts adds the counts of several locations, reporting found if any exist
+func sumCounts(results ...func() (int, bool)) (int, bool) {
+	total, found := 0, false
+	for _, result := range results {
+		if count, ok := result(); ok {
+			total += count
+			found = true
+		}
+	}
+	return total, found
+}
+
+func isDir(entry fs.DirEntry) bool {
+	return entry.IsDir()
 }
 
-// GetInfo returns the number of installed packages
-func (p *PackagesInfo) GetInfo() string {
-	// Check for pacman (Arch-based distributions)
-	if _, err := exec.LookPath("pacman"); err == nil {
-		out, err := common.GlobalCommandExecutor.Execute("pacman", "-Qq")
-		if err == nil {
-			packages := strings.Split(out, "\n")
-			return fmt.Sprintf("%d", len(packages)-1) // Subtract 1 to account for the empty line at the end
+// countPacman counts the per-package directories of the local pacman database
+func countPacman(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	return countEntries(fsys, path.Join("var", "lib", "pacman", "local"), isDir)
+}
+
+// countDpkg counts the stanzas of the dpkg status file that are fully installed
+func countDpkg(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	return countLines(fsys, path.Join("var", "lib", "dpkg", "status"), func(line string) bool {
+		return strings.HasPrefix(line, "Status: ") && strings.HasSuffix(line, " installed")
+	})
+}
+
+// countRpm asks rpm, as its database is a SQLite or Berkeley DB file
+func countRpm(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	if _, err := fs.Stat(fsys, path.Join("var", "lib", "rpm")); err != nil {
+		return 0, false
+	}
+	out, err := executor.Execute("rpm", "-qa")
+	if err != nil {
+		return 0, false
+	}
+	return countNonEmpty(out), true
+}
+
+// countApk counts the package records of the apk installed database
+func countApk(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	return countLines(fsys, path.Join("lib", "apk", "db", "installed"), func(line string) bool {
+		return strings.HasPrefix(line, "P:")
+	})
+}
+
+// countPortage counts category/package directories of the Portage database
+func countPortage(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	categories, err := fs.ReadDir(fsys, path.Join("var", "db", "pkg"))
+	if err != nil {
+		return 0, false
+	}
+
+	total := 0
+	for _, category := range categories {
+		if !category.IsDir() {
+			continue
 		}
+		if count, ok := countEntries(fsys, path.Join("var", "db", "pkg", category.Name()), isDir); ok {
+			total += count
+		}
+	}
+	return total, true
+}
+
+// countNix counts the store paths the system and user profiles depend on.
+// The closures of the profiles overlap, so each path is counted once.
+func countNix(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	profiles := []string{
+		path.Join("run", "current-system"),
+		path.Join("nix", "var", "nix", "profiles", "default"),
+		path.Join(home, ".nix-profile"),
 	}
 
-	// Check for dpkg (Debian-based distributions)
-	if _, err := exec.LookPath("dpkg"); err == nil {
-		out, err := common.GlobalCommandExecutor.Execute("dpkg-query", "-f", "${binary:Package}\n", "-W")
-		if err == nil {
-			packages := strings.Split(out, "\n")
-			return fmt.Sprintf("%d", len(packages)-1) // Subtract 1 to account for the empty line at the end
+	paths := make(map[string]bool)
+	found := false
+	for _, profile := range profiles {
+		if _, err := fs.Stat(fsys, profile); err != nil {
+			continue
+		}
+		out, err := executor.Execute("nix-store", "--query", "--requisites", "/"+profile)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if path := strings.TrimSpace(line); path != "" {
+				paths[path] = true
+			}
 		}
+		found = true
 	}
+	return len(paths), found
+}
 
-	// Check for rpm (Red Hat-based distributions)
-	if _, err := exec.LookPath("rpm"); err == nil {
-		out, err := common.GlobalCommandExecutor.Execute("rpm", "-qa")
-		if err == nil {
-			packages := strings.Split(out, "\n")
-			return fmt.Sprintf("%d", len(packages)-1) // Subtract 1 to account for the empty line at the end
+// countFlatpak counts installed refs (name/arch/branch) of system and user installations
+func countFlatpak(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	var results []func() (int, bool)
+	for _, installation := range []string{
+		path.Join("var", "lib", "flatpak"),
+		path.Join(home, ".local", "share", "flatpak"),
+	} {
+		for _, kind := range []string{"app", "runtime"} {
+			dir := path.Join(installation, kind)
+			results = append(results, func() (int, bool) { return countFlatpakRefs(fsys, dir) })
 		}
 	}
+	return sumCounts(results...)
+}
 
-	// Check for flatpak
-	if _, err := exec.LookPath("flatpak"); err == nil {
-		out, err := common.GlobalCommandExecutor.Execute("flatpak", "list")
-		if err == nil {
-			packages := strings.Split(out, "\n")
-			if len(packages) > 1 { // If there's at least one package (plus the empty line)
-				return fmt.Sprintf("%d", len(packages)-1)
+func countFlatpakRefs(fsys fs.FS, dir string) (int, bool) {
+	names, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return 0, false
+	}
+
+	total := 0
+	for _, name := range names {
+		arches, err := fs.ReadDir(fsys, path.Join(dir, name.Name()))
+		if err != nil {
+			continue
+		}
+		for _, arch := range arches {
+			// "current" is a symlink to the active arch/branch
+			if arch.Name() == "current" || !arch.IsDir() {
+				continue
 			}
+			if count, ok := countEntries(fsys, path.Join(dir, name.Name(), arch.Name()), isDir); ok {
+				total += count
+			}
+		}
+	}
+	return total, true
+}
+
+// countSnap counts the mounted snaps below /snap
+func countSnap(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	return countEntries(fsys, "snap", func(entry fs.DirEntry) bool {
+		return entry.IsDir() && entry.Name() != "bin"
+	})
+}
+
+// countBrew counts formulae and casks in every known Homebrew prefix
+func countBrew(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	prefixes := []string{
+		path.Join("home", "linuxbrew", ".linuxbrew"),
+		path.Join(home, ".linuxbrew"),
+		path.Join("opt", "homebrew"),
+		path.Join("usr", "local"),
+	}
+
+	var results []func() (int, bool)
+	for _, prefix := range prefixes {
+		for _, dir := range []string{"Cellar", "Caskroom"} {
+			installed := path.Join(prefix, dir)
+			results = append(results, func() (int, bool) { return countEntries(fsys, installed, isDir) })
 		}
 	}
+	return sumCounts(results...)
+}
+
+// countPip counts distributions installed with "pip install --user"
+func countPip(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	sitePackages, _ := fs.Glob(fsys, path.Join(home, ".local", "lib", "python3*", "site-packages"))
+
+	var results []func() (int, bool)
+	for _, dir := range sitePackages {
+		results = append(results, func() (int, bool) {
+			return countEntries(fsys, dir, func(entry fs.DirEntry) bool {
+				return entry.IsDir() && strings.HasSuffix(entry.Name(), ".dist-info")
+			})
+		})
+	}
+	return sumCounts(results...)
+}
+
+// countCargo counts crates installed with "cargo install" from the v1 manifest
+func countCargo(fsys fs.FS, executor common.Executor, home string) (int, bool) {
+	return countLines(fsys, path.Join(cargoHomePath(home), ".crates.toml"), func(line string) bool {
+		return strings.HasPrefix(line, `"`)
+	})
+}
+
+// cargoHomePath returns the fs path of $CARGO_HOME, defaulting to ~/.cargo
+func cargoHomePath(home string) string {
+	if cargoHome := os.Getenv("CARGO_HOME"); cargoHome != "" {
+		return fsPath(cargoHome)
+	}
+	return path.Join(home, ".cargo")
+}
 
-	return "Unknown"
+// countNonEmpty counts the non-empty lines of command output
+func countNonEmpty(out string) int {
+	count := 0
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			count++
+		}
+	}
+	return count
 }
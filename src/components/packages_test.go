@@ -0,0 +1,153 @@
+package components
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func newPackagesInfo(t *testing.T, fsys fixtureFS, outputs map[string]string, options string) (*PackagesInfo, *fakeExecutor) {
+	t.Helper()
+	clearEnv(t, "CARGO_HOME")
+
+	executor := newFakeExecutor(outputs)
+	info := &PackagesInfo{SystemInfo: SystemInfo{FS: fsys, Exec: executor}, Home: "/home/luna"}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info, executor
+}
+
+// workstation has dpkg, pacman, flatpak, snap, pip and cargo installs
+func workstation(t *testing.T) fixtureFS {
+	return newFixtureFS(map[string]string{
+		"var/lib/dpkg/status": testdata(t, "dpkg-status"),
+
+		"var/lib/pacman/local/ALPM_DB_VERSION":       "9\n",
+		"var/lib/pacman/local/bash-5.2.026-2/desc":   "",
+		"var/lib/pacman/local/glibc-2.40+r16-1/desc": "",
+
+		"var/lib/flatpak/app/org.mozilla.firefox/x86_64/stable/active/metadata":               "",
+		"var/lib/flatpak/runtime/org.freedesktop.Platform/x86_64/23.08/active/metadata":       "",
+		"var/lib/flatpak/runtime/org.freedesktop.Platform/x86_64/24.08/active/metadata":       "",
+		"home/luna/.local/share/flatpak/app/com.spotify.Client/x86_64/stable/active/metadata": "",
+
+		"snap/core22/1380/meta/snap.yaml": "",
+		"snap/bin/firefox":                "",
+
+		"home/luna/.local/lib/python3.12/site-packages/requests-2.32.3.dist-info/METADATA": "",
+		"home/luna/.local/lib/python3.12/site-packages/requests/__init__.py":               "",
+
+		"home/luna/.cargo/.crates.toml": "[v1]\n\"ripgrep 14.1.0 (registry+https://github.com/rust-lang/crates.io-index)\" = [\"rg\"]\n\"bat 0.24.0 (registry+https://github.com/rust-lang/crates.io-index)\" = [\"bat\"]\n",
+	})
+}
+
+func TestPackagesInfo(t *testing.T) {
+	info, _ := newPackagesInfo(t, workstation(t), nil, "")
+	result := info.GetInfo()
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	want := "2 (pacman), 3 (dpkg), 4 (flatpak), 1 (snap), 1 (pip), 2 (cargo)"
+	if result.Value != want {
+		t.Errorf("Value = %q, want %q", result.Value, want)
+	}
+	if result.Fields["count"] != 13 {
+		t.Errorf("count = %v, want 13", result.Fields["count"])
+	}
+}
+
+func TestPackagesInfoManagersOption(t *testing.T) {
+	info, _ := newPackagesInfo(t, workstation(t), nil, `{"managers": ["DPKG", "cargo"]}`)
+	if value := info.GetInfo().Value; value != "3 (dpkg), 2 (cargo)" {
+		t.Errorf("Value = %q", value)
+	}
+}
+
+func TestPackagesInfoCommands(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"var/lib/rpm/rpmdb.sqlite":         "",
+		"nix/var/nix/profiles/default/bin": "",
+	})
+	info, executor := newPackagesInfo(t, fsys, map[string]string{
+		"rpm -qa": "bash-5.2.26-3.fc40.x86_64\nglibc-2.39-17.fc40.x86_64\n\nkernel-6.10.6-200.fc40.x86_64\n",
+		"nix-store --query --requisites /nix/var/nix/profiles/default": "/nix/store/a-hello-2.12.1\n/nix/store/b-glibc-2.39\n",
+	}, "")
+
+	if value := info.GetInfo().Value; value != "3 (rpm), 2 (nix)" {
+		t.Errorf("Value = %q", value)
+	}
+	if executor.called("nix-store --query --requisites /run/current-system") {
+		t.Error("nix-store was queried for a profile that does not exist")
+	}
+}
+
+func TestPackagesInfoNixSharedPaths(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"run/current-system/sw":            "",
+		"nix/var/nix/profiles/default/bin": "",
+		"home/luna/.nix-profile/bin":       "",
+	})
+	info, _ := newPackagesInfo(t, fsys, map[string]string{
+		"nix-store --query --requisites /run/current-system":           "/nix/store/a-glibc-2.39\n/nix/store/b-bash-5.2\n/nix/store/c-nixos-system\n",
+		"nix-store --query --requisites /nix/var/nix/profiles/default": "/nix/store/a-glibc-2.39\n/nix/store/d-hello-2.12.1\n",
+		"nix-store --query --requisites /home/luna/.nix-profile":       "/nix/store/a-glibc-2.39\n/nix/store/b-bash-5.2\n/nix/store/e-ripgrep-14.1\n",
+	}, `{"managers": ["nix"]}`)
+
+	// glibc and bash are in more than one closure and count once
+	if value := info.GetInfo().Value; value != "5 (nix)" {
+		t.Errorf("Value = %q", value)
+	}
+}
+
+func TestPackagesInfoNone(t *testing.T) {
+	info, _ := newPackagesInfo(t, newFixtureFS(nil), nil, "")
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+}
+
+func TestPackagesCacheKeyTracksDatabases(t *testing.T) {
+	fsys := workstation(t)
+	info, _ := newPackagesInfo(t, fsys, nil, "")
+
+	before, ok := info.CacheKey()
+	if !ok || before == "" {
+		t.Fatalf("CacheKey() = %q, %v", before, ok)
+	}
+
+	fsys.MapFS["var/lib/dpkg/status"].ModTime = fsys.MapFS["var/lib/dpkg/status"].ModTime.Add(1)
+	if after, _ := info.CacheKey(); after == before {
+		t.Error("CacheKey did not change after the dpkg database was modified")
+	}
+}
+
+func TestPackagesCacheKeyTracksNixProfiles(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"home/luna/.local/state/nix/profiles/profile-3-link/manifest.json": "",
+	})
+	fsys.link("run/current-system", "/nix/store/a-nixos-system-24.05")
+	fsys.link("home/luna/.nix-profile", "/home/luna/.local/state/nix/profiles/profile")
+	fsys.link("home/luna/.local/state/nix/profiles/profile", "profile-3-link")
+	info, _ := newPackagesInfo(t, fsys, nil, "")
+
+	// Store paths all have the same modification time, so only the link
+	// targets tell generations apart
+	steps := []struct {
+		name, link, target string
+	}{
+		{"nix profile install", "home/luna/.local/state/nix/profiles/profile", "profile-4-link"},
+		{"nixos-rebuild switch", "run/current-system", "/nix/store/b-nixos-system-24.05"},
+	}
+	before, _ := info.CacheKey()
+	for _, step := range steps {
+		fsys.link(step.link, step.target)
+		after, _ := info.CacheKey()
+		if after == before {
+			t.Errorf("CacheKey did not change after %s", step.name)
+		}
+		before = after
+	}
+}
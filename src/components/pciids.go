@@ -0,0 +1,83 @@
+package components
+
+import (
+	"bufio"
+	"io/fs"
+	"strings"
+)
+
+// DefaultPCIIDsPaths are the locations distributions install the pci.ids
+// database to, relative to the root filesystem
+var DefaultPCIIDsPaths = []string{
+	"usr/share/hwdata/pci.ids",
+	"usr/share/misc/pci.ids",
+	"usr/share/pci.ids",
+	"usr/local/share/pci.ids",
+}
+
+// lookupPCIName returns the vendor and device names for a PCI ID pair from
+// the first readable pci.ids database. IDs are lowercase hex without "0x".
+func lookupPCIName(fsys fs.FS, paths []string, vendorID, deviceID string) (string, string, bool) {
+	for _, path := range paths {
+		file, err := fsys.Open(path)
+		if err != nil {
+			continue
+		}
+
+		vendor, device, found := scanPCIIDs(bufio.NewScanner(file), vendorID, deviceID)
+		file.Close()
+		if found {
+			return vendor, device, true
+		}
+	}
+	return "", "", false
+}
+
+// scanPCIIDs walks pci.ids: vendors start at column zero, their devices are
+// indented by one tab and subsystems by two.
+func scanPCIIDs(scanner *bufio.Scanner, vendorID, deviceID string) (string, string, bool) {
+	vendor := ""
+	inVendor := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] != '\t' {
+			if inVendor {
+				break
+			}
+			// The device class section at the end of the file uses "C xx" lines
+			if strings.HasPrefix(line, "C ") {
+				break
+			}
+			id, name, ok := splitPCIIDLine(line)
+			if ok && id == vendorID {
+				vendor = name
+				inVendor = true
+			}
+			continue
+		}
+
+		if !inVendor || strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+
+		id, name, ok := splitPCIIDLine(line[1:])
+		if ok && id == deviceID {
+			return vendor, name, true
+		}
+	}
+
+	return vendor, "", vendor != ""
+}
+
+func splitPCIIDLine(line string) (string, string, bool) {
+	id, name, found := strings.Cut(line, "  ")
+	if !found {
+		return "", "", false
+	}
+	return strings.ToLower(strings.TrimSpace(id)), strings.TrimSpace(name), true
+}
@@ -0,0 +1,107 @@
+package components
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Process is an entry of the process tree read from procfs
+type Process struct {
+	PID  int
+	PPID int
+	// Comm is the kernel's name for the process, truncated to 15 bytes
+	Comm string
+	// Command is the base name of argv[0], without the "-" login shells get
+	Command string
+}
+
+// Matches reports whether the process' comm or command is one of names
+func (p Process) Matches(names map[string]bool) bool {
+	return names[p.Comm] || names[p.Command]
+}
+
+// readProcess reads the parent PID from /proc/<pid>/stat and the names from
+// /proc/<pid>/comm and /proc/<pid>/cmdline
+func readProcess(fsys fs.FS, pid int) (Process, error) {
+	dir := path.Join(procDir, strconv.Itoa(pid))
+
+	stat, err := fs.ReadFile(fsys, path.Join(dir, "stat"))
+	if err != nil {
+		return Process{}, err
+	}
+
+	// The comm field is parenthesised and may itself contain spaces or ")"
+	open := strings.IndexByte(string(stat), '(')
+	end := strings.LastIndexByte(string(stat), ')')
+	if open < 0 || end < open {
+		return Process{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	rest := strings.Fields(string(stat[end+1:]))
+	if len(rest) < 2 {
+		return Process{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	ppid, err := strconv.Atoi(rest[1])
+	if err != nil {
+		return Process{}, fmt.Errorf("malformed stat for pid %d: %w", pid, err)
+	}
+
+	process := Process{PID: pid, PPID: ppid, Comm: string(stat[open+1 : end])}
+	if comm, err := fs.ReadFile(fsys, path.Join(dir, "comm")); err == nil {
+		process.Comm = strings.TrimSpace(string(comm))
+	}
+	if cmdline, err := fs.ReadFile(fsys, path.Join(dir, "cmdline")); err == nil {
+		process.Command = commandName(cmdline)
+	}
+	return process, nil
+}
+
+// commandName extracts the program name from a NUL separated cmdline.
+// Processes such as sshd rewrite argv[0] to "sshd: user@pts/0", so only the
+// first word is used.
+func commandName(cmdline []byte) string {
+	argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+	fields := strings.Fields(argv0)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := filepath.Base(fields[0])
+	name = strings.TrimPrefix(name, "-")
+	return strings.TrimSuffix(name, ":")
+}
+
+// processAncestors returns pid and its ancestors, nearest first, stopping
+// before init
+func processAncestors(fsys fs.FS, pid int) []Process {
+	var ancestors []Process
+	seen := make(map[int]bool)
+
+	for pid > 1 && !seen[pid] {
+		seen[pid] = true
+		process, err := readProcess(fsys, pid)
+		if err != nil {
+			break
+		}
+		ancestors = append(ancestors, process)
+		pid = process.PPID
+	}
+	return ancestors
+}
+
+// knownShells lists shell binaries skipped when looking for the terminal
+var knownShells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true,
+	"ksh": true, "mksh": true, "oksh": true, "tcsh": true, "csh": true,
+	"nu": true, "elvish": true, "xonsh": true, "ion": true, "pwsh": true,
+	"osh": true, "ysh": true, "yash": true, "busybox": true,
+}
+
+// processWrappers lists programs that sit between a shell and its terminal
+var processWrappers = map[string]bool{
+	"sudo": true, "doas": true, "su": true, "login": true, "script": true,
+	"run0": true, "env": true, "time": true, "nohup": true, "strace": true,
+	"lunarfetch": true,
+}
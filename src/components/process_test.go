@@ -0,0 +1,83 @@
+package components
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// addProcess adds /proc/<pid>/{stat,comm,cmdline} for a process whose
+// parent is ppid
+func addProcess(fsys fixtureFS, pid, ppid int, comm string, argv ...string) {
+	dir := path.Join(procDir, strconv.Itoa(pid))
+	fsys.MapFS[path.Join(dir, "stat")] = mapFile(fmt.Sprintf("%d (%s) S %d %d %d 34816 0\n", pid, comm, ppid, pid, ppid))
+	fsys.MapFS[path.Join(dir, "comm")] = mapFile(comm + "\n")
+	fsys.MapFS[path.Join(dir, "cmdline")] = mapFile(strings.Join(argv, "\x00") + "\x00")
+}
+
+func TestReadProcess(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	// comm may itself contain spaces and parentheses
+	addProcess(fsys, 42, 7, "tmux: server", "tmux", "new-session")
+	fsys.MapFS["proc/43/stat"] = mapFile("43 (weird) name)) R 42 43 42 0 -1\n")
+
+	process, err := readProcess(fsys, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Process{PID: 42, PPID: 7, Comm: "tmux: server", Command: "tmux"}); process != want {
+		t.Errorf("readProcess(42) = %+v, want %+v", process, want)
+	}
+
+	process, err = readProcess(fsys, 43)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if process.PPID != 42 || process.Comm != "weird) name)" {
+		t.Errorf("readProcess(43) = %+v", process)
+	}
+
+	if _, err := readProcess(fsys, 44); err == nil {
+		t.Error("readProcess of a missing pid succeeded")
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"-zsh\x00":                           "zsh",
+		"/usr/bin/kitty\x00--single\x00":     "kitty",
+		"sshd-session: luna@pts/0\x00":       "sshd-session",
+		"/usr/libexec/gnome-terminal-server": "gnome-terminal-server",
+		"":                                   "",
+	}
+	for cmdline, want := range tests {
+		if got := commandName([]byte(cmdline)); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", cmdline, got, want)
+		}
+	}
+}
+
+func TestProcessAncestors(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	addProcess(fsys, 1, 0, "systemd", "/sbin/init")
+	addProcess(fsys, 800, 1, "kitty", "kitty")
+	addProcess(fsys, 900, 800, "zsh", "-zsh")
+
+	var pids []int
+	for _, process := range processAncestors(fsys, 900) {
+		pids = append(pids, process.PID)
+	}
+	if want := []int{900, 800}; !reflect.DeepEqual(pids, want) {
+		t.Errorf("ancestors = %v, want %v", pids, want)
+	}
+
+	// A cycle must not loop forever
+	addProcess(fsys, 5, 6, "a", "a")
+	addProcess(fsys, 6, 5, "b", "b")
+	if ancestors := processAncestors(fsys, 5); len(ancestors) != 2 {
+		t.Errorf("cycle: got %d ancestors", len(ancestors))
+	}
+}
@@ -0,0 +1,74 @@
+package components
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registration describes an InfoProvider and the defaults used to display it
+type Registration struct {
+	// Key identifies the module in config.json ("icons.<key>", "modules.show_<key>")
+	Key string
+	// Label is the text printed before the module value
+	Label string
+	// Icon is the default icon used when the config does not set one
+	Icon string
+	// Enabled reports whether the module is shown when the config does not mention it
+	Enabled bool
+	// Order is the default position of the module in the output
+	Order int
+	// Priority decides which modules are hidden first when the output is
+	// taller than the terminal; lower goes first, zero means DefaultPriority
+	Priority int
+	// CacheTTL is how long results may be reused from the disk cache; zero
+	// means the module is always collected fresh
+	CacheTTL time.Duration
+	// New creates a fresh provider instance
+	New func() InfoProvider
+}
+
+// DefaultPriority is the priority of modules that don't set one
+const DefaultPriority = 50
+
+var (
+	registry      = make(map[string]Registration)
+	registryMutex sync.RWMutex
+)
+
+// Register adds a provider to the registry, replacing any previous registration with the same key
+func Register(r Registration) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	registry[r.Key] = r
+}
+
+// Lookup returns the registration for the given key
+func Lookup(key string) (Registration, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	r, ok := registry[key]
+	return r, ok
+}
+
+// Registered returns all registrations sorted by their default order
+func Registered() []Registration {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	registrations := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		registrations = append(registrations, r)
+	}
+
+	sort.Slice(registrations, func(i, j int) bool {
+		if registrations[i].Order != registrations[j].Order {
+			return registrations[i].Order < registrations[j].Order
+		}
+		return registrations[i].Key < registrations[j].Key
+	})
+
+	return registrations
+}
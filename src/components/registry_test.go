@@ -0,0 +1,26 @@
+package components
+
+import "testing"
+
+func TestRegistered(t *testing.T) {
+	registrations := Registered()
+	if len(registrations) == 0 {
+		t.Fatal("no components registered")
+	}
+
+	for i, reg := range registrations {
+		if i > 0 && registrations[i-1].Order > reg.Order {
+			t.Errorf("%q (order %d) sorted after %q (order %d)", reg.Key, reg.Order, registrations[i-1].Key, registrations[i-1].Order)
+		}
+		if found, ok := Lookup(reg.Key); !ok || found.Label != reg.Label {
+			t.Errorf("Lookup(%q) = %+v, %v", reg.Key, found, ok)
+		}
+		if provider := reg.New(); provider == nil || provider.GetName() == "" {
+			t.Errorf("%q creates a provider without a name", reg.Key)
+		}
+	}
+
+	if _, ok := Lookup("does-not-exist"); ok {
+		t.Error("Lookup of an unknown key succeeded")
+	}
+}
@@ -4,18 +4,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "resolution",
+		Label:    "Resolution",
+		Icon:     "󰍹",
+		Enabled:  true,
+		Order:    140,
+		Priority: 30,
+		New:      func() InfoProvider { return &ResolutionInfo{SystemInfo: SystemInfo{Name: "Resolution"}} },
+	})
+}
+
 // ResolutionInfo provides screen resolution information
 type ResolutionInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the screen resolution
-func (r *ResolutionInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("xrandr")
+func (r *ResolutionInfo) GetInfo() Result {
+	out, err := r.executor().Execute("xrandr")
 	if err == nil {
 		lines := strings.Split(out, "\n")
 		for _, line := range lines {
@@ -23,14 +33,16 @@ func (r *ResolutionInfo) GetInfo() string {
 				fields := strings.Fields(line)
 				for _, field := range fields {
 					if strings.Contains(field, "x") {
-						return field
+						// Drop the +X+Y offset of the output's geometry
+						geometry, _, _ := strings.Cut(field, "+")
+						return resolutionResult(geometry)
 					}
 				}
 			}
 		}
 	}
 
-	if out, err := common.GlobalCommandExecutor.Execute("swaymsg", "-t", "get_outputs"); err == nil {
+	if out, err := r.executor().Execute("swaymsg", "-t", "get_outputs"); err == nil {
 		var outputs []struct {
 			CurrentMode struct {
 				Width  int `json:"width"`
@@ -38,33 +50,44 @@ func (r *ResolutionInfo) GetInfo() string {
 			} `json:"current_mode"`
 		}
 		if err := json.Unmarshal([]byte(out), &outputs); err == nil && len(outputs) > 0 {
-			return fmt.Sprintf("%dx%d", outputs[0].CurrentMode.Width, outputs[0].CurrentMode.Height)
+			return resolutionResult(fmt.Sprintf("%dx%d", outputs[0].CurrentMode.Width, outputs[0].CurrentMode.Height))
 		}
 	}
 
-	if out, err := common.GlobalCommandExecutor.Execute("wlr-randr"); err == nil {
+	if out, err := r.executor().Execute("wlr-randr"); err == nil {
 		lines := strings.Split(out, "\n")
 		for _, line := range lines {
 			if strings.Contains(line, "current") {
 				fields := strings.Fields(line)
 				for _, field := range fields {
 					if strings.Contains(field, "x") {
-						return field
+						return resolutionResult(field)
 					}
 				}
 			}
 		}
 	}
 
-	if out, err := common.GlobalCommandExecutor.Execute("xdpyinfo"); err == nil {
+	if out, err := r.executor().Execute("xdpyinfo"); err == nil {
 		lines := strings.Split(out, "\n")
 		for _, line := range lines {
 			if strings.Contains(line, "dimensions:") {
 				fields := strings.Fields(line)
-				return fields[1]
+				return resolutionResult(fields[1])
 			}
 		}
 	}
 
-	return "Unknown"
+	return ErrorResult(ErrNotFound)
+}
+
+// resolutionResult parses a WIDTHxHEIGHT string into a result
+func resolutionResult(resolution string) Result {
+	fields := Fields{}
+	var width, height int
+	if _, err := fmt.Sscanf(resolution, "%dx%d", &width, &height); err == nil {
+		fields["width"] = width
+		fields["height"] = height
+	}
+	return NewResult(resolution, fields)
 }
@@ -0,0 +1,46 @@
+package components
+
+import "testing"
+
+func TestResolutionInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		want    string
+	}{
+		{"xrandr", map[string]string{"xrandr": testdata(t, "xrandr.txt")}, "1920x1080"},
+		{"sway", map[string]string{"swaymsg -t get_outputs": testdata(t, "swaymsg-outputs.json")}, "3840x2160"},
+		{"wlr-randr", map[string]string{"wlr-randr": testdata(t, "wlr-randr.txt")}, "2256x1504"},
+		{"xdpyinfo", map[string]string{"xdpyinfo": testdata(t, "xdpyinfo.txt")}, "3440x1440"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info := &ResolutionInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(test.outputs)}}
+			result := info.GetInfo()
+			if result.Value != test.want {
+				t.Errorf("Value = %q, want %q", result.Value, test.want)
+			}
+		})
+	}
+}
+
+func TestResolutionInfoFields(t *testing.T) {
+	executor := newFakeExecutor(map[string]string{"swaymsg -t get_outputs": testdata(t, "swaymsg-outputs.json")})
+	info := &ResolutionInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: executor}}
+
+	result := info.GetInfo()
+	if result.Fields["width"] != 3840 || result.Fields["height"] != 2160 {
+		t.Errorf("Fields = %v", result.Fields)
+	}
+	if !executor.called("xrandr") {
+		t.Error("xrandr was not tried first")
+	}
+}
+
+func TestResolutionInfoNoDisplay(t *testing.T) {
+	info := &ResolutionInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+}
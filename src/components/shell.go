@@ -1,29 +1,234 @@
 package components
 
 import (
+	"encoding/json"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "shell",
+		Label:   "Shell",
+		Icon:    "󰆍",
+		Enabled: true,
+		Order:   70,
+		New: func() InfoProvider {
+			return &ShellInfo{SystemInfo: SystemInfo{Name: "Shell"}, Options: DefaultShellOptions()}
+		},
+	})
+}
+
+// shellVersionQueries run a shell binary and return the raw version output
+var shellVersionQueries = map[string]func(executor common.Executor, exe string) string{
+	"bash":   versionFlag("--version"),
+	"zsh":    versionFlag("--version"),
+	"fish":   versionFlag("--version"),
+	"nu":     versionFlag("--version"),
+	"elvish": versionFlag("-version"),
+	"tcsh":   versionFlag("--version"),
+	"xonsh":  versionFlag("--version"),
+	"pwsh":   versionFlag("--version"),
+	"yash":   versionFlag("--version"),
+	"dash":   dashVersion,
+}
+
+func versionFlag(flag string) func(executor common.Executor, exe string) string {
+	return func(executor common.Executor, exe string) string {
+		out, _ := executor.Execute(exe, flag)
+		return out
+	}
+}
+
+// dashVersion asks the package manager, as dash has no version flag
+func dashVersion(executor common.Executor, exe string) string {
+	if out, err := executor.Execute("dpkg-query", "-W", "-f=${Version}", "dash"); err == nil {
+		return out
+	}
+	if out, err := executor.Execute("pacman", "-Q", "dash"); err == nil {
+		return out
+	}
+	return ""
+}
+
+var versionNumber = regexp.MustCompile(`\d+\.\d+(?:\.\d+)?`)
+
+// shellVersionTTL bounds how long a version is trusted without re-querying,
+// in case an upgrade keeps the binary's size and modification time
+const shellVersionTTL = 30 * 24 * time.Hour
+
+// ShellOptions configures the shell line
+type ShellOptions struct {
+	// Version appends the shell version
+	Version bool `json:"version"`
+	// LoginShell adds the login shell from $SHELL when it differs from the running one
+	LoginShell bool `json:"loginShell"`
+}
+
+// DefaultShellOptions returns the options used when config.json sets none
+func DefaultShellOptions() ShellOptions {
+	return ShellOptions{Version: true}
+}
+
+// Shell describes a shell binary
+type Shell struct {
+	Name    string
+	Path    string
+	Version string
+}
+
+// String renders the shell as "zsh 5.9"
+func (s Shell) String() string {
+	return strings.TrimSpace(s.Name + " " + s.Version)
+}
+
 // ShellInfo provides shell information
 type ShellInfo struct {
 	SystemInfo
+	// PID is the process to start looking from; 0 uses lunarfetch's parent
+	PID int
+	// Cache holds shell versions between runs; nil queries the version on
+	// every run
+	Cache   *common.DiskCache
+	Options ShellOptions
+}
+
+// UseCache stores shell versions in the module cache
+func (s *ShellInfo) UseCache(cache *common.DiskCache) {
+	s.Cache = cache
 }
 
-// GetInfo returns the shell name
-func (s *ShellInfo) GetInfo() string {
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		out, err := common.GlobalCommandExecutor.Execute("getent", "passwd", os.Getenv("USER"))
+// Configure applies the "shell" options from config.json
+func (s *ShellInfo) Configure(options json.RawMessage) error {
+	opts := DefaultShellOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	s.Options = opts
+	return nil
+}
+
+// GetInfo returns the running shell and its version
+func (s *ShellInfo) GetInfo() Result {
+	login, hasLogin := s.loginShell()
+	running, found := s.runningShell()
+	if !found {
+		if !hasLogin {
+			return ErrorResult(ErrNotFound)
+		}
+		running = login
+	}
+
+	if s.Options.Version {
+		running.Version = s.version(running)
+	}
+
+	result := NewResult(running.String(), Fields{
+		"name":       running.Name,
+		"path":       running.Path,
+		"version":    running.Version,
+		"login_name": login.Name,
+		"login_path": login.Path,
+	})
+
+	if s.Options.LoginShell && hasLogin && login.Name != running.Name {
+		if s.Options.Version {
+			login.Version = s.version(login)
+		}
+		result.Lines = append(result.Lines, Line{Label: "Login Shell", Value: login.String()})
+	}
+	return result
+}
+
+// runningShell returns the nearest shell among lunarfetch's ancestors
+func (s *ShellInfo) runningShell() (Shell, bool) {
+	fsys := s.rootFS()
+	pid := s.PID
+	if pid == 0 {
+		pid = os.Getppid()
+	}
+
+	for _, process := range processAncestors(fsys, pid) {
+		if process.Matches(processWrappers) {
+			continue
+		}
+		if !process.Matches(knownShells) {
+			break
+		}
+
+		name := process.Command
+		if !knownShells[name] {
+			name = process.Comm
+		}
+		shell := Shell{Name: name}
+		if exe, err := readLink(fsys, path.Join(procDir, strconv.Itoa(process.PID), "exe")); err == nil {
+			shell.Path = exe
+		} else if path, err := exec.LookPath(name); err == nil {
+			shell.Path = path
+		}
+		return shell, true
+	}
+	return Shell{}, false
+}
+
+// loginShell returns the shell from $SHELL, or from the passwd entry
+func (s *ShellInfo) loginShell() (Shell, bool) {
+	path := os.Getenv("SHELL")
+	if path == "" {
+		out, err := s.executor().Execute("getent", "passwd", os.Getenv("USER"))
 		if err == nil {
 			fields := strings.Split(out, ":")
 			if len(fields) >= 7 {
-				shell = fields[6]
+				path = strings.TrimSpace(fields[6])
 			}
 		}
 	}
-	return filepath.Base(shell)
+	if path == "" {
+		return Shell{}, false
+	}
+	return Shell{Name: filepath.Base(path), Path: path}, true
+}
+
+// version returns the shell's version, from the cache when the binary has
+// not changed since it was last queried
+func (s *ShellInfo) version(shell Shell) string {
+	query, ok := shellVersionQueries[shell.Name]
+	if !ok {
+		return ""
+	}
+	exe := shell.Path
+	if exe == "" {
+		exe = shell.Name
+	}
+
+	executor := s.executor()
+
+	// The binary's size and modification time change whenever it is upgraded
+	info, err := fs.Stat(s.rootFS(), fsPath(exe))
+	if s.Cache == nil || err != nil || !filepath.IsAbs(exe) {
+		return versionNumber.FindString(query(executor, exe))
+	}
+	stamp := strconv.FormatInt(info.Size(), 10) + ":" + strconv.FormatInt(info.ModTime().UnixNano(), 10)
+
+	name := "shell-version:" + exe
+
+	var version string
+	if s.Cache.Get(name, stamp, &version) {
+		return version
+	}
+
+	version = versionNumber.FindString(query(executor, exe))
+	if version != "" {
+		s.Cache.Set(name, stamp, shellVersionTTL, version)
+	}
+	return version
 }
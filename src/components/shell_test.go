@@ -0,0 +1,135 @@
+package components
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"lunarfetch/src/common"
+)
+
+func newShellInfo(t *testing.T, fsys fixtureFS, executor *fakeExecutor, pid int, options string) *ShellInfo {
+	t.Helper()
+	clearEnv(t, "SHELL", "USER")
+	info := &ShellInfo{
+		SystemInfo: SystemInfo{FS: fsys, Exec: executor},
+		PID:        pid,
+		Cache:      common.NewDiskCache(t.TempDir()),
+		Options:    DefaultShellOptions(),
+	}
+	if options != "" {
+		if err := info.Configure(json.RawMessage(options)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return info
+}
+
+// zshInKitty runs zsh from /usr/bin/zsh inside kitty
+func zshInKitty() fixtureFS {
+	fsys := newFixtureFS(map[string]string{"usr/bin/zsh": "\x7fELF"})
+	addProcess(fsys, 800, 1, "kitty", "kitty")
+	addProcess(fsys, 900, 800, "zsh", "-zsh")
+	fsys.link("proc/900/exe", "/usr/bin/zsh")
+	return fsys
+}
+
+func TestShellInfoRunningShell(t *testing.T) {
+	executor := newFakeExecutor(map[string]string{"/usr/bin/zsh --version": "zsh 5.9 (x86_64-pc-linux-gnu)\n"})
+	info := newShellInfo(t, zshInKitty(), executor, 900, "")
+	t.Setenv("SHELL", "/bin/bash")
+
+	result := info.GetInfo()
+	if result.Value != "zsh 5.9" {
+		t.Errorf("Value = %q, want zsh 5.9", result.Value)
+	}
+	if result.Fields["path"] != "/usr/bin/zsh" || result.Fields["login_name"] != "bash" {
+		t.Errorf("Fields = %v", result.Fields)
+	}
+	if len(result.Lines) != 0 {
+		t.Errorf("login shell shown without the option: %v", result.Lines)
+	}
+}
+
+func TestShellInfoVersionCache(t *testing.T) {
+	fsys := zshInKitty()
+	executor := newFakeExecutor(map[string]string{"/usr/bin/zsh --version": "zsh 5.9 (x86_64-pc-linux-gnu)\n"})
+	info := newShellInfo(t, fsys, executor, 900, "")
+
+	if value := info.GetInfo().Value; value != "zsh 5.9" {
+		t.Fatalf("Value = %q", value)
+	}
+
+	// The cached version is used while the binary is unchanged
+	delete(executor.outputs, "/usr/bin/zsh --version")
+	if value := info.GetInfo().Value; value != "zsh 5.9" {
+		t.Errorf("cached Value = %q", value)
+	}
+
+	// An upgrade changes the binary and invalidates the cache
+	fsys.MapFS["usr/bin/zsh"] = mapFile("\x7fELF upgraded")
+	executor.outputs["/usr/bin/zsh --version"] = "zsh 5.9.1 (x86_64-pc-linux-gnu)\n"
+	if value := info.GetInfo().Value; value != "zsh 5.9.1" {
+		t.Errorf("upgraded Value = %q", value)
+	}
+}
+
+func TestShellInfoWithoutCache(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	executor := newFakeExecutor(map[string]string{"/usr/bin/zsh --version": "zsh 5.9 (x86_64-pc-linux-gnu)\n"})
+	info := newShellInfo(t, zshInKitty(), executor, 900, "")
+	info.Cache = nil
+
+	// With the module cache turned off the version is queried on every run
+	// and nothing is written
+	info.GetInfo()
+	executor.outputs["/usr/bin/zsh --version"] = "zsh 5.9.1 (x86_64-pc-linux-gnu)\n"
+	if value := info.GetInfo().Value; value != "zsh 5.9.1" {
+		t.Errorf("Value = %q", value)
+	}
+	if entries, _ := os.ReadDir(cacheHome); len(entries) != 0 {
+		t.Errorf("cache written: %v", entries)
+	}
+}
+
+func TestShellInfoLoginShell(t *testing.T) {
+	executor := newFakeExecutor(map[string]string{
+		"/usr/bin/zsh --version": "zsh 5.9 (x86_64-pc-linux-gnu)",
+		"/bin/bash --version":    "GNU bash, version 5.2.15(1)-release (x86_64-pc-linux-gnu)\nCopyright (C) 2022 Free Software Foundation, Inc.",
+	})
+	info := newShellInfo(t, zshInKitty(), executor, 900, `{"loginShell": true}`)
+	t.Setenv("SHELL", "/bin/bash")
+
+	result := info.GetInfo()
+	if len(result.Lines) != 1 || result.Lines[0] != (Line{"Login Shell", "bash 5.2.15"}) {
+		t.Errorf("Lines = %v", result.Lines)
+	}
+}
+
+func TestShellInfoFallsBackToPasswd(t *testing.T) {
+	// lunarfetch started directly by the terminal, without a shell in between
+	fsys := newFixtureFS(nil)
+	addProcess(fsys, 800, 1, "foot", "foot")
+
+	executor := newFakeExecutor(map[string]string{
+		"getent passwd luna": "luna:x:1000:1000:Luna:/home/luna:/usr/bin/fish",
+	})
+	info := newShellInfo(t, fsys, executor, 800, `{"version": false}`)
+	t.Setenv("USER", "luna")
+
+	result := info.GetInfo()
+	if result.Value != "fish" || result.Fields["path"] != "/usr/bin/fish" {
+		t.Errorf("got %q, path %v", result.Value, result.Fields["path"])
+	}
+	if executor.called("/usr/bin/fish --version") {
+		t.Error("version queried although disabled")
+	}
+}
+
+func TestShellInfoNotFound(t *testing.T) {
+	info := newShellInfo(t, newFixtureFS(nil), newFakeExecutor(nil), 900, "")
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+}
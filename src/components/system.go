@@ -0,0 +1,114 @@
+package components
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lunarfetch/src/common"
+)
+
+// HostFS is the root filesystem of the running machine
+var HostFS fs.FS = os.DirFS("/")
+
+// Locations of the kernel filesystems inside the root filesystem
+const (
+	sysfsDir = "sys"
+	procDir  = "proc"
+)
+
+// SystemInfo provides basic system information and the machine a component
+// inspects. Components read files through FS and run commands through Exec,
+// so tests can substitute fixtures for both.
+type SystemInfo struct {
+	Name string
+	// FS is the root filesystem with paths relative to "/"; nil reads HostFS
+	FS fs.FS
+	// Exec runs external commands; nil uses common.GlobalCommandExecutor
+	Exec common.Executor
+}
+
+// SystemBinder is implemented by every component embedding SystemInfo
+type SystemBinder interface {
+	UseSystem(fsys fs.FS, executor common.Executor)
+}
+
+// CacheUser is implemented by components that keep data of their own in the
+// module cache, which is only handed to them when caching is enabled
+type CacheUser interface {
+	UseCache(cache *common.DiskCache)
+}
+
+// GetName returns the name of this info provider
+func (s *SystemInfo) GetName() string {
+	return s.Name
+}
+
+// UseSystem replaces the filesystem and executor; nil keeps the current one
+func (s *SystemInfo) UseSystem(fsys fs.FS, executor common.Executor) {
+	if fsys != nil {
+		s.FS = fsys
+	}
+	if executor != nil {
+		s.Exec = executor
+	}
+}
+
+// rootFS returns the filesystem the component reads from
+func (s *SystemInfo) rootFS() fs.FS {
+	if s.FS == nil {
+		return HostFS
+	}
+	return s.FS
+}
+
+// executor returns the executor the component runs commands with
+func (s *SystemInfo) executor() common.Executor {
+	if s.Exec == nil {
+		return common.GlobalCommandExecutor
+	}
+	return s.Exec
+}
+
+// fsPath converts an absolute host path into a path of the root filesystem
+func fsPath(hostPath string) string {
+	cleaned := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(hostPath)), "/")
+	if cleaned == "" {
+		return "."
+	}
+	return cleaned
+}
+
+// homePath returns the fs path of home, or of the current user's home directory when empty
+func homePath(home string) string {
+	if home == "" {
+		home, _ = os.UserHomeDir()
+	}
+	return fsPath(home)
+}
+
+// readLinkFS is implemented by filesystems that can resolve symlinks
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// readLink returns the target of the symlink name in fsys
+func readLink(fsys fs.FS, name string) (string, error) {
+	if fsys == HostFS {
+		return os.Readlink("/" + name)
+	}
+	if linker, ok := fsys.(readLinkFS); ok {
+		return linker.ReadLink(name)
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+}
+
+// linkBase returns the last element of a symlink target, or "" if name is not a link
+func linkBase(fsys fs.FS, name string) string {
+	target, err := readLink(fsys, name)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
@@ -0,0 +1,161 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"lunarfetch/src/common"
+)
+
+// fakeExecutor replays recorded command output keyed by "name arg1 arg2".
+// Commands without a recording fail as if the binary were not installed.
+type fakeExecutor struct {
+	outputs map[string]string
+	errors  map[string]error
+
+	mutex sync.Mutex
+	calls []string
+}
+
+func newFakeExecutor(outputs map[string]string) *fakeExecutor {
+	return &fakeExecutor{outputs: outputs, errors: make(map[string]error)}
+}
+
+func (f *fakeExecutor) Execute(name string, args ...string) (string, error) {
+	command := strings.Join(append([]string{name}, args...), " ")
+
+	f.mutex.Lock()
+	f.calls = append(f.calls, command)
+	f.mutex.Unlock()
+
+	if err, ok := f.errors[command]; ok {
+		return "", err
+	}
+	if out, ok := f.outputs[command]; ok {
+		return strings.TrimSpace(out), nil
+	}
+	return "", fmt.Errorf("%s: %w", name, exec.ErrNotFound)
+}
+
+func (f *fakeExecutor) ExecuteContext(ctx context.Context, name string, args ...string) (string, error) {
+	return f.Execute(name, args...)
+}
+
+func (f *fakeExecutor) ExecuteWithStdin(name string, args ...string) (string, error) {
+	return f.Execute(name, args...)
+}
+
+// called reports whether command was run
+func (f *fakeExecutor) called(command string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, call := range f.calls {
+		if call == command {
+			return true
+		}
+	}
+	return false
+}
+
+var _ common.Executor = (*fakeExecutor)(nil)
+
+// fixtureFS is a MapFS root filesystem that also resolves the symlinks in links
+type fixtureFS struct {
+	fstest.MapFS
+	links map[string]string
+}
+
+func (f fixtureFS) ReadLink(name string) (string, error) {
+	if target, ok := f.links[name]; ok {
+		return target, nil
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrNotExist}
+}
+
+// newFixtureFS builds a root filesystem from file contents keyed by path
+func newFixtureFS(files map[string]string) fixtureFS {
+	fsys := fixtureFS{MapFS: fstest.MapFS{}, links: make(map[string]string)}
+	for name, data := range files {
+		fsys.MapFS[name] = mapFile(data)
+	}
+	return fsys
+}
+
+// mapFile returns a fixture file holding data
+func mapFile(data string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(data)}
+}
+
+// link adds a symlink at name pointing to target
+func (f fixtureFS) link(name, target string) {
+	f.links[name] = target
+}
+
+// testdata returns the content of a recorded fixture below testdata/
+func testdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// clearEnv unsets the variables components read, so tests do not depend on
+// the session running them
+func clearEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestFsPath(t *testing.T) {
+	tests := map[string]string{
+		"/":                  ".",
+		"/home/luna":         "home/luna",
+		"/home/luna/":        "home/luna",
+		"/usr//share/../lib": "usr/lib",
+	}
+	for hostPath, want := range tests {
+		if got := fsPath(hostPath); got != want {
+			t.Errorf("fsPath(%q) = %q, want %q", hostPath, got, want)
+		}
+	}
+}
+
+func TestUseSystemKeepsUnsetValues(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	executor := newFakeExecutor(nil)
+
+	info := &SystemInfo{Name: "Test"}
+	if info.rootFS() != HostFS || info.executor() != common.GlobalCommandExecutor {
+		t.Fatal("zero SystemInfo should use the host filesystem and global executor")
+	}
+
+	info.UseSystem(fsys, executor)
+	info.UseSystem(nil, nil)
+	if _, ok := info.rootFS().(fixtureFS); !ok {
+		t.Error("UseSystem(nil, nil) replaced the filesystem")
+	}
+	if info.executor() != executor {
+		t.Error("UseSystem(nil, nil) replaced the executor")
+	}
+}
+
+func TestRegisteredComponentsBindSystem(t *testing.T) {
+	for _, reg := range Registered() {
+		if _, ok := reg.New().(SystemBinder); !ok {
+			t.Errorf("component %q does not accept a filesystem and executor", reg.Key)
+		}
+	}
+}
@@ -1,27 +1,297 @@
 package components
 
 import (
+	"encoding/json"
+	"io/fs"
 	"os"
+	"strconv"
 	"strings"
 
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "terminal",
+		Label:   "Terminal",
+		Icon:    "󰆍",
+		Enabled: true,
+		Order:   60,
+		New: func() InfoProvider {
+			return &TerminalInfo{SystemInfo: SystemInfo{Name: "Terminal"}, Options: DefaultTerminalOptions()}
+		},
+	})
+}
+
+// terminalEmulator describes a known terminal binary
+type terminalEmulator struct {
+	Name string
+	// Font reads the configured font from the terminal's config files;
+	// configDir and home are paths within fsys
+	Font func(fsys fs.FS, configDir, home string) string
+}
+
+// terminalEmulators maps process names to terminals. gnome-terminal-server
+// is also listed under its comm, which the kernel truncates to 15 bytes.
+var terminalEmulators = map[string]terminalEmulator{
+	"kitty":                 {"kitty", kittyFont},
+	"alacritty":             {"Alacritty", alacrittyFont},
+	"foot":                  {"foot", footFont},
+	"footclient":            {"foot", footFont},
+	"wezterm":               {"WezTerm", weztermFont},
+	"wezterm-gui":           {"WezTerm", weztermFont},
+	"ghostty":               {"Ghostty", ghosttyFont},
+	"gnome-terminal-server": {"GNOME Terminal", nil},
+	"gnome-terminal-":       {"GNOME Terminal", nil},
+	"konsole":               {"Konsole", konsoleFont},
+	"xfce4-terminal":        {"Xfce Terminal", xfceTerminalFont},
+	"kgx":                   {"GNOME Console", nil},
+	"ptyxis":                {"Ptyxis", nil},
+	"ptyxis-agent":          {"Ptyxis", nil},
+	"tilix":                 {"Tilix", nil},
+	"terminator":            {"Terminator", nil},
+	"mate-terminal":         {"MATE Terminal", nil},
+	"lxterminal":            {"LXTerminal", nil},
+	"qterminal":             {"QTerminal", nil},
+	"terminology":           {"Terminology", nil},
+	"urxvt":                 {"URxvt", nil},
+	"urxvtd":                {"URxvt", nil},
+	"xterm":                 {"XTerm", nil},
+	"st":                    {"st", nil},
+	"contour":               {"Contour", nil},
+	"rio":                   {"Rio", nil},
+	"code":                  {"VS Code", nil},
+}
+
+// terminalMultiplexers lists multiplexer process names
+var terminalMultiplexers = map[string]bool{
+	"tmux": true, "tmux: server": true, "tmux: client": true,
+	"screen": true, "SCREEN": true, "zellij": true,
+}
+
+// remoteSessions lists the servers of remote login sessions
+var remoteSessions = map[string]bool{
+	"sshd": true, "sshd-session": true, "dropbear": true, "mosh-server": true,
+}
+
+// TerminalOptions configures the extra terminal lines
+type TerminalOptions struct {
+	// Font adds the font configured for the terminal, when it can be read
+	Font bool `json:"font"`
+	// Multiplexer adds the tmux, screen or zellij session the shell runs in
+	Multiplexer bool `json:"multiplexer"`
+	// SSH adds the client address of an SSH session
+	SSH bool `json:"ssh"`
+}
+
+// DefaultTerminalOptions returns the options used when config.json sets none
+func DefaultTerminalOptions() TerminalOptions {
+	return TerminalOptions{Font: true, Multiplexer: true, SSH: true}
+}
+
+// TerminalSession describes where lunarfetch is running
+type TerminalSession struct {
+	Name        string
+	Process     string
+	PID         int
+	Multiplexer string
+	SSH         bool
+	SSHClient   string
+	Font        string
+}
+
 // TerminalInfo provides terminal information
 type TerminalInfo struct {
 	SystemInfo
+	// PID is the process to start walking from; 0 uses lunarfetch's parent
+	PID int
+	// Home is the user's home directory for config files; empty uses $HOME
+	Home    string
+	Options TerminalOptions
+}
+
+// Configure applies the "terminal" options from config.json
+func (t *TerminalInfo) Configure(options json.RawMessage) error {
+	opts := DefaultTerminalOptions()
+	if err := json.Unmarshal(options, &opts); err != nil {
+		return err
+	}
+	t.Options = opts
+	return nil
 }
 
-// GetInfo returns the terminal name
-func (t *TerminalInfo) GetInfo() string {
-	term := os.Getenv("TERM")
-	if term == "" {
-		out, err := common.GlobalCommandExecutor.Execute("ps", "-p", os.Getenv("$"), "-o", "args=")
-		if err == nil && out != "" {
-			term = strings.TrimSpace(out)
+// GetInfo returns the terminal emulator, multiplexer, SSH session and font
+func (t *TerminalInfo) GetInfo() Result {
+	session := t.Detect()
+	if session.Name == "" {
+		return ErrorResult(ErrNotFound)
+	}
+
+	result := NewResult(session.Name, Fields{
+		"name":        session.Name,
+		"process":     session.Process,
+		"pid":         session.PID,
+		"multiplexer": session.Multiplexer,
+		"ssh":         session.SSH,
+		"ssh_client":  session.SSHClient,
+		"font":        session.Font,
+	})
+
+	if t.Options.Font && session.Font != "" {
+		result.Lines = append(result.Lines, Line{Label: "Terminal Font", Value: session.Font})
+	}
+	if t.Options.Multiplexer && session.Multiplexer != "" {
+		result.Lines = append(result.Lines, Line{Label: "Multiplexer", Value: session.Multiplexer})
+	}
+	if t.Options.SSH && session.SSH {
+		value := "connected"
+		if session.SSHClient != "" {
+			value = "from " + session.SSHClient
+		}
+		result.Lines = append(result.Lines, Line{Label: "SSH", Value: value})
+	}
+	return result
+}
+
+// Detect walks up the process tree past shells, wrappers and multiplexers
+// until it reaches the terminal emulator or a remote login server
+func (t *TerminalInfo) Detect() TerminalSession {
+	fsys := t.rootFS()
+	pid := t.PID
+	if pid == 0 {
+		pid = os.Getppid()
+	}
+
+	var session TerminalSession
+	var terminal *Process
+
+walk:
+	for _, process := range processAncestors(fsys, pid) {
+		switch {
+		case process.Matches(knownShells) || process.Matches(processWrappers):
+			continue
+		case process.Matches(terminalMultiplexers):
+			if session.Multiplexer != "" {
+				continue
+			}
+			session.Multiplexer = multiplexerName(process)
+			// The tmux server is detached from the terminal; continue from the
+			// client attached to this session instead
+			if session.Multiplexer == "tmux" {
+				if client := tmuxClientPID(t.executor()); client > 0 {
+					if found := t.walkFrom(fsys, client); found != nil {
+						terminal = found
+					}
+				}
+			}
+			break walk
+		case process.Matches(remoteSessions):
+			session.SSH = true
+			break walk
+		default:
+			found := process
+			terminal = &found
+			break walk
+		}
+	}
+
+	if session.Multiplexer == "" {
+		switch {
+		case os.Getenv("TMUX") != "":
+			session.Multiplexer = "tmux"
+		case os.Getenv("STY") != "":
+			session.Multiplexer = "screen"
+		case os.Getenv("ZELLIJ") != "":
+			session.Multiplexer = "zellij"
+		}
+	}
+
+	if connection := strings.Fields(os.Getenv("SSH_CONNECTION")); len(connection) > 0 {
+		session.SSH = true
+		session.SSHClient = connection[0]
+	} else if os.Getenv("SSH_TTY") != "" {
+		session.SSH = true
+	}
+
+	if terminal != nil {
+		session.PID = terminal.PID
+		session.Process = terminal.Command
+		if session.Process == "" {
+			session.Process = terminal.Comm
+		}
+
+		emulator, known := terminalEmulators[terminal.Command]
+		if !known {
+			emulator, known = terminalEmulators[terminal.Comm]
+		}
+		if known {
+			session.Name = emulator.Name
+			if emulator.Font != nil {
+				home := homePath(t.Home)
+				session.Font = emulator.Font(fsys, configHome(home), home)
+			}
 		} else {
-			term = "Unknown"
+			session.Name = session.Process
+		}
+	}
+
+	if session.Name == "" {
+		session.Name = terminalFromEnv()
+	}
+	return session
+}
+
+// walkFrom returns the first ancestor of pid that is not a shell, wrapper or
+// multiplexer, or nil when the walk ends at a remote session or init
+func (t *TerminalInfo) walkFrom(fsys fs.FS, pid int) *Process {
+	for _, process := range processAncestors(fsys, pid) {
+		if process.Matches(knownShells) || process.Matches(processWrappers) || process.Matches(terminalMultiplexers) {
+			continue
+		}
+		if process.Matches(remoteSessions) {
+			return nil
 		}
+		return &process
+	}
+	return nil
+}
+
+func multiplexerName(process Process) string {
+	switch {
+	case strings.HasPrefix(process.Comm, "tmux") || process.Command == "tmux":
+		return "tmux"
+	case strings.EqualFold(process.Comm, "screen") || strings.EqualFold(process.Command, "screen"):
+		return "screen"
+	default:
+		return process.Comm
+	}
+}
+
+// tmuxClientPID asks tmux for the most recently active client of the current session
+func tmuxClientPID(executor common.Executor) int {
+	if os.Getenv("TMUX") == "" {
+		return 0
+	}
+	out, err := executor.Execute("tmux", "display-message", "-p", "#{client_pid}")
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(out))
+	return pid
+}
+
+// terminalFromEnv names the terminal from the environment when the process
+// tree gives no answer, e.g. over SSH or on the Linux console
+func terminalFromEnv() string {
+	if program := os.Getenv("TERM_PROGRAM"); program != "" {
+		return program
+	}
+	switch term := os.Getenv("TERM"); term {
+	case "":
+		return ""
+	case "linux":
+		return "Linux console"
+	default:
+		return term
 	}
-	return term
 }
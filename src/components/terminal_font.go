@@ -0,0 +1,219 @@
+package components
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// configHome returns the fs path of $XDG_CONFIG_HOME, or ~/.config when it is unset
+func configHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return fsPath(dir)
+	}
+	return path.Join(home, ".config")
+}
+
+// scanConfigLines calls fn for every non-empty, non-comment line of path
+// and reports whether the file could be read
+func scanConfigLines(fsys fs.FS, path string, fn func(line string)) bool {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		fn(line)
+	}
+	return true
+}
+
+// formatFont renders a family and an optional point size as "Family 11"
+func formatFont(family, size string) string {
+	family = strings.Trim(strings.TrimSpace(family), `"'`)
+	if family == "" {
+		return ""
+	}
+	size = strings.TrimSpace(size)
+	if value, err := strconv.ParseFloat(size, 64); err == nil && value > 0 {
+		return family + " " + strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return family
+}
+
+// kittyFont reads font_family and font_size from kitty.conf
+func kittyFont(fsys fs.FS, configDir, home string) string {
+	var family, size string
+	scanConfigLines(fsys, path.Join(configDir, "kitty", "kitty.conf"), func(line string) {
+		fields := strings.Fields(line)
+		value := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+		switch fields[0] {
+		case "font_family":
+			family = value
+		case "font_size":
+			size = value
+		}
+	})
+	return formatFont(family, size)
+}
+
+var (
+	tomlFamily = regexp.MustCompile(`family\s*=\s*"([^"]+)"`)
+	yamlFamily = regexp.MustCompile(`^family:\s*(.+)$`)
+	yamlSize   = regexp.MustCompile(`^size:\s*([\d.]+)`)
+)
+
+// alacrittyFont reads the normal font family and size from alacritty.toml,
+// or from the older alacritty.yml
+func alacrittyFont(fsys fs.FS, configDir, home string) string {
+	var family, size, section string
+	found := scanConfigLines(fsys, path.Join(configDir, "alacritty", "alacritty.toml"), func(line string) {
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			return
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return
+		}
+		key = strings.TrimSpace(key)
+		switch {
+		case section == "font.normal" && key == "family":
+			family = strings.TrimSpace(value)
+		case section == "font" && key == "normal":
+			// Inline table: normal = { family = "...", style = "..." }
+			if match := tomlFamily.FindStringSubmatch(value); match != nil {
+				family = match[1]
+			}
+		case section == "font" && key == "size":
+			size = value
+		}
+	})
+
+	if !found {
+		scanConfigLines(fsys, path.Join(configDir, "alacritty", "alacritty.yml"), func(line string) {
+			if match := yamlFamily.FindStringSubmatch(line); match != nil && family == "" {
+				family = match[1]
+			}
+			if match := yamlSize.FindStringSubmatch(line); match != nil {
+				size = match[1]
+			}
+		})
+	}
+	return formatFont(family, size)
+}
+
+// footFont reads the first font of foot.ini's font= key ("Family:size=11")
+func footFont(fsys fs.FS, configDir, home string) string {
+	var family, size string
+	scanConfigLines(fsys, path.Join(configDir, "foot", "foot.ini"), func(line string) {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "font" {
+			return
+		}
+		first, _, _ := strings.Cut(value, ",")
+		parts := strings.Split(first, ":")
+		family = parts[0]
+		for _, attribute := range parts[1:] {
+			if name, value, ok := strings.Cut(attribute, "="); ok && name == "size" {
+				size = value
+			}
+		}
+	})
+	return formatFont(family, size)
+}
+
+var (
+	weztermFamily = regexp.MustCompile(`wezterm\.font(?:_with_fallback)?\s*\(\s*\{?\s*(?:family\s*=\s*)?["']([^"']+)["']`)
+	weztermSize   = regexp.MustCompile(`font_size\s*=\s*([\d.]+)`)
+)
+
+// weztermFont looks for wezterm.font(...) and font_size in the Lua config
+func weztermFont(fsys fs.FS, configDir, home string) string {
+	for _, path := range []string{
+		path.Join(configDir, "wezterm", "wezterm.lua"),
+		path.Join(home, ".wezterm.lua"),
+	} {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			continue
+		}
+		var family, size string
+		if match := weztermFamily.FindSubmatch(data); match != nil {
+			family = string(match[1])
+		}
+		if match := weztermSize.FindSubmatch(data); match != nil {
+			size = string(match[1])
+		}
+		return formatFont(family, size)
+	}
+	return ""
+}
+
+// ghosttyFont reads font-family and font-size from Ghostty's config
+func ghosttyFont(fsys fs.FS, configDir, home string) string {
+	var family, size string
+	scanConfigLines(fsys, path.Join(configDir, "ghostty", "config"), func(line string) {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return
+		}
+		switch strings.TrimSpace(key) {
+		case "font-family":
+			// The first font-family wins; later ones are fallbacks
+			if family == "" {
+				family = value
+			}
+		case "font-size":
+			size = value
+		}
+	})
+	return formatFont(family, size)
+}
+
+// konsoleFont reads the Font= entry of Konsole's default profile
+func konsoleFont(fsys fs.FS, configDir, home string) string {
+	profile := ""
+	scanConfigLines(fsys, path.Join(configDir, "konsolerc"), func(line string) {
+		if value, ok := strings.CutPrefix(line, "DefaultProfile="); ok {
+			profile = value
+		}
+	})
+	if profile == "" {
+		return ""
+	}
+
+	var family, size string
+	scanConfigLines(fsys, path.Join(home, ".local", "share", "konsole", profile), func(line string) {
+		// Qt font description: "Hack,10,-1,5,50,0,0,0,0,0"
+		if value, ok := strings.CutPrefix(line, "Font="); ok {
+			parts := strings.Split(value, ",")
+			family = parts[0]
+			if len(parts) > 1 {
+				size = parts[1]
+			}
+		}
+	})
+	return formatFont(family, size)
+}
+
+// xfceTerminalFont reads FontName= ("Monospace 12") from terminalrc
+func xfceTerminalFont(fsys fs.FS, configDir, home string) string {
+	font := ""
+	scanConfigLines(fsys, path.Join(configDir, "xfce4", "terminal", "terminalrc"), func(line string) {
+		if value, ok := strings.CutPrefix(line, "FontName="); ok {
+			font = strings.TrimSpace(value)
+		}
+	})
+	return font
+}
@@ -0,0 +1,163 @@
+package components
+
+import (
+	"io/fs"
+	"testing"
+)
+
+// terminalEnv lists the variables terminal detection consults
+var terminalEnv = []string{
+	"TMUX", "STY", "ZELLIJ", "SSH_CONNECTION", "SSH_TTY", "TERM_PROGRAM", "TERM", "XDG_CONFIG_HOME",
+}
+
+func newTerminalInfo(t *testing.T, fsys fixtureFS, outputs map[string]string, pid int) *TerminalInfo {
+	t.Helper()
+	clearEnv(t, terminalEnv...)
+	return &TerminalInfo{
+		SystemInfo: SystemInfo{FS: fsys, Exec: newFakeExecutor(outputs)},
+		PID:        pid,
+		Home:       "/home/luna",
+		Options:    DefaultTerminalOptions(),
+	}
+}
+
+func TestTerminalInfoKitty(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"home/luna/.config/kitty/kitty.conf": "# kitty.conf\nfont_family\tJetBrains Mono\nfont_size 11.5\n",
+	})
+	addProcess(fsys, 1, 0, "systemd", "/sbin/init")
+	addProcess(fsys, 800, 1, "kitty", "/usr/bin/kitty")
+	addProcess(fsys, 900, 800, "zsh", "-zsh")
+
+	result := newTerminalInfo(t, fsys, nil, 900).GetInfo()
+	if result.Value != "kitty" {
+		t.Errorf("Value = %q, want kitty", result.Value)
+	}
+	if len(result.Lines) != 1 || result.Lines[0] != (Line{"Terminal Font", "JetBrains Mono 11.5"}) {
+		t.Errorf("Lines = %v", result.Lines)
+	}
+	if result.Fields["pid"] != 800 {
+		t.Errorf("pid = %v", result.Fields["pid"])
+	}
+}
+
+func TestTerminalInfoSkipsWrappers(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"home/luna/.config/foot/foot.ini": "[main]\nfont=Fira Code:size=10,Noto Color Emoji:size=9\n",
+	})
+	addProcess(fsys, 800, 1, "foot", "foot")
+	addProcess(fsys, 850, 800, "bash", "bash")
+	addProcess(fsys, 870, 850, "sudo", "sudo", "-i")
+	addProcess(fsys, 900, 870, "bash", "-bash")
+
+	result := newTerminalInfo(t, fsys, nil, 900).GetInfo()
+	if result.Value != "foot" || result.Fields["font"] != "Fira Code 10" {
+		t.Errorf("got %q, font %v", result.Value, result.Fields["font"])
+	}
+}
+
+func TestTerminalInfoTruncatedComm(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	addProcess(fsys, 800, 1, "gnome-terminal-", "/usr/libexec/gnome-terminal-server")
+	addProcess(fsys, 900, 800, "bash", "bash")
+
+	if value := newTerminalInfo(t, fsys, nil, 900).GetInfo().Value; value != "GNOME Terminal" {
+		t.Errorf("Value = %q", value)
+	}
+}
+
+func TestTerminalInfoTmuxClient(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"home/luna/.config/alacritty/alacritty.toml": "[font]\nsize = 12.0\n\n[font.normal]\nfamily = \"Iosevka Term\"\n",
+	})
+	// The shell runs below the tmux server, which was started by systemd
+	addProcess(fsys, 850, 1, "tmux: server", "tmux")
+	addProcess(fsys, 900, 850, "zsh", "-zsh")
+	// The client attached to the session runs inside Alacritty
+	addProcess(fsys, 500, 1, "alacritty", "alacritty")
+	addProcess(fsys, 600, 500, "zsh", "-zsh")
+	addProcess(fsys, 700, 600, "tmux: client", "tmux", "attach")
+
+	info := newTerminalInfo(t, fsys, map[string]string{"tmux display-message -p #{client_pid}": "700\n"}, 900)
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,850,0")
+
+	result := info.GetInfo()
+	if result.Value != "Alacritty" {
+		t.Errorf("Value = %q, want Alacritty", result.Value)
+	}
+	want := []Line{{"Terminal Font", "Iosevka Term 12"}, {"Multiplexer", "tmux"}}
+	if len(result.Lines) != len(want) || result.Lines[0] != want[0] || result.Lines[1] != want[1] {
+		t.Errorf("Lines = %v, want %v", result.Lines, want)
+	}
+}
+
+func TestTerminalInfoSSH(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	addProcess(fsys, 880, 1, "sshd-session", "sshd-session: luna@pts/0")
+	addProcess(fsys, 900, 880, "bash", "-bash")
+
+	info := newTerminalInfo(t, fsys, nil, 900)
+	t.Setenv("SSH_CONNECTION", "203.0.113.7 51234 192.0.2.10 22")
+	t.Setenv("TERM", "xterm-256color")
+
+	result := info.GetInfo()
+	if result.Value != "xterm-256color" {
+		t.Errorf("Value = %q, want the $TERM fallback", result.Value)
+	}
+	if len(result.Lines) != 1 || result.Lines[0] != (Line{"SSH", "from 203.0.113.7"}) {
+		t.Errorf("Lines = %v", result.Lines)
+	}
+}
+
+func TestTerminalInfoLinuxConsole(t *testing.T) {
+	fsys := newFixtureFS(nil)
+	addProcess(fsys, 700, 1, "login", "/bin/login", "--")
+	addProcess(fsys, 900, 700, "bash", "-bash")
+
+	info := newTerminalInfo(t, fsys, nil, 900)
+	t.Setenv("TERM", "linux")
+
+	if value := info.GetInfo().Value; value != "Linux console" {
+		t.Errorf("Value = %q", value)
+	}
+}
+
+func TestTerminalFonts(t *testing.T) {
+	tests := []struct {
+		name string
+		font func(fsys fs.FS, configDir, home string) string
+		file string
+		data string
+		want string
+	}{
+		{"alacritty inline table", alacrittyFont, "home/luna/.config/alacritty/alacritty.toml",
+			"[font]\nnormal = { family = \"Hack\", style = \"Regular\" }\nsize = 10\n", "Hack 10"},
+		{"alacritty yaml", alacrittyFont, "home/luna/.config/alacritty/alacritty.yml",
+			"font:\n  normal:\n    family: Source Code Pro\n  size: 9.5\n", "Source Code Pro 9.5"},
+		{"wezterm", weztermFont, "home/luna/.wezterm.lua",
+			"local wezterm = require 'wezterm'\nreturn {\n  font = wezterm.font('Cascadia Code'),\n  font_size = 13.0,\n}\n", "Cascadia Code 13"},
+		{"ghostty", ghosttyFont, "home/luna/.config/ghostty/config",
+			"font-family = Berkeley Mono\nfont-family = Symbols Nerd Font\nfont-size = 14\n", "Berkeley Mono 14"},
+		{"xfce4-terminal", xfceTerminalFont, "home/luna/.config/xfce4/terminal/terminalrc",
+			"[Configuration]\nFontName=Monospace 12\n", "Monospace 12"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fsys := newFixtureFS(map[string]string{test.file: test.data})
+			if got := test.font(fsys, "home/luna/.config", "home/luna"); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestKonsoleFont(t *testing.T) {
+	fsys := newFixtureFS(map[string]string{
+		"home/luna/.config/konsolerc":                 "[Desktop Entry]\nDefaultProfile=Dark.profile\n",
+		"home/luna/.local/share/konsole/Dark.profile": "[Appearance]\nColorScheme=Breeze\nFont=Hack,10,-1,5,50,0,0,0,0,0\n",
+	})
+	if got := konsoleFont(fsys, "home/luna/.config", "home/luna"); got != "Hack 10" {
+		t.Errorf("konsoleFont = %q", got)
+	}
+}
@@ -1,43 +1,72 @@
 package components
 
 import (
+	"path"
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "wm_theme",
+		Label:    "WM Theme",
+		Icon:     "󰏘",
+		Enabled:  true,
+		Order:    150,
+		Priority: 20,
+		New:      func() InfoProvider { return &WMThemeInfo{SystemInfo: SystemInfo{Name: "WM Theme"}} },
+	})
+	Register(Registration{
+		Key:      "theme",
+		Label:    "Theme",
+		Icon:     "󰔯",
+		Enabled:  true,
+		Order:    160,
+		Priority: 20,
+		New:      func() InfoProvider { return &ThemeInfo{SystemInfo: SystemInfo{Name: "Theme"}} },
+	})
+	Register(Registration{
+		Key:      "icons",
+		Label:    "Icons",
+		Icon:     "󰀻",
+		Enabled:  true,
+		Order:    170,
+		Priority: 20,
+		New:      func() InfoProvider { return &IconsInfo{SystemInfo: SystemInfo{Name: "Icons"}} },
+	})
+}
+
 // ThemeInfo provides theme information
 type ThemeInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the current theme
-func (t *ThemeInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme")
+func (t *ThemeInfo) GetInfo() Result {
+	out, err := t.executor().Execute("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme")
 	if err == nil {
 		theme := strings.TrimSpace(out)
 		theme = strings.Trim(theme, "'")
-		return theme
+		return NewResult(theme, Fields{"name": theme})
 	}
 
-	out, err = common.GlobalCommandExecutor.Execute("dconf", "read", "/org/gnome/desktop/interface/gtk-theme")
+	out, err = t.executor().Execute("dconf", "read", "/org/gnome/desktop/interface/gtk-theme")
 	if err == nil {
 		theme := strings.TrimSpace(out)
 		theme = strings.Trim(theme, "'")
-		return theme
+		return NewResult(theme, Fields{"name": theme})
 	}
 
-	out, err = common.GlobalCommandExecutor.Execute("grep", "gtk-theme-name", "~/.gtkrc-2.0")
-	if err == nil {
-		parts := strings.Split(out, "=")
-		if len(parts) > 1 {
-			theme := strings.TrimSpace(parts[1])
-			theme = strings.Trim(theme, "\"")
-			return theme
+	theme := ""
+	scanConfigLines(t.rootFS(), path.Join(homePath(""), ".gtkrc-2.0"), func(line string) {
+		if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "gtk-theme-name" {
+			theme = strings.Trim(strings.TrimSpace(value), "\"")
 		}
+	})
+	if theme != "" {
+		return NewResult(theme, Fields{"name": theme})
 	}
 
-	return "Unknown"
+	return ErrorResult(ErrNotFound)
 }
 
 // WMThemeInfo provides window manager theme information
@@ -46,14 +75,14 @@ type WMThemeInfo struct {
 }
 
 // GetInfo returns the window manager theme
-func (w *WMThemeInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("gsettings", "get", "org.gnome.desktop.wm.preferences", "theme")
+func (w *WMThemeInfo) GetInfo() Result {
+	out, err := w.executor().Execute("gsettings", "get", "org.gnome.desktop.wm.preferences", "theme")
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
 	theme := strings.TrimSpace(out)
 	theme = strings.Trim(theme, "'")
-	return theme
+	return NewResult(theme, Fields{"name": theme})
 }
 
 // IconsInfo provides icon theme information
@@ -62,12 +91,12 @@ type IconsInfo struct {
 }
 
 // GetInfo returns the icon theme
-func (i *IconsInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("gsettings", "get", "org.gnome.desktop.interface", "icon-theme")
+func (i *IconsInfo) GetInfo() Result {
+	out, err := i.executor().Execute("gsettings", "get", "org.gnome.desktop.interface", "icon-theme")
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
 	theme := strings.TrimSpace(out)
 	theme = strings.Trim(theme, "'")
-	return theme
+	return NewResult(theme, Fields{"name": theme})
 }
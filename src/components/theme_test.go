@@ -0,0 +1,60 @@
+package components
+
+import "testing"
+
+func TestThemeInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		files   map[string]string
+		want    string
+	}{
+		{"gsettings", map[string]string{
+			"gsettings get org.gnome.desktop.interface gtk-theme": "'Adwaita-dark'\n",
+		}, nil, "Adwaita-dark"},
+		{"dconf", map[string]string{
+			"dconf read /org/gnome/desktop/interface/gtk-theme": "'Arc-Dark'\n",
+		}, nil, "Arc-Dark"},
+		{"gtkrc", nil, map[string]string{
+			"home/luna/.gtkrc-2.0": "# Generated by lxappearance\ngtk-theme-name=\"Materia-dark\"\ngtk-icon-theme-name=\"Papirus\"\n",
+		}, "Materia-dark"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("HOME", "/home/luna")
+			info := &ThemeInfo{SystemInfo: SystemInfo{FS: newFixtureFS(test.files), Exec: newFakeExecutor(test.outputs)}}
+			if result := info.GetInfo(); result.Value != test.want {
+				t.Errorf("Value = %q, want %q", result.Value, test.want)
+			}
+		})
+	}
+}
+
+func TestThemeInfoNotFound(t *testing.T) {
+	t.Setenv("HOME", "/home/luna")
+	info := &ThemeInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if result := info.GetInfo(); result.Err == nil {
+		t.Errorf("expected an error, got %q", result.Value)
+	}
+}
+
+func TestWMThemeAndIconsInfo(t *testing.T) {
+	executor := newFakeExecutor(map[string]string{
+		"gsettings get org.gnome.desktop.wm.preferences theme": "'Adwaita'\n",
+		"gsettings get org.gnome.desktop.interface icon-theme": "'Papirus-Dark'\n",
+	})
+	system := SystemInfo{FS: newFixtureFS(nil), Exec: executor}
+
+	if value := (&WMThemeInfo{SystemInfo: system}).GetInfo().Value; value != "Adwaita" {
+		t.Errorf("WM theme = %q", value)
+	}
+	if value := (&IconsInfo{SystemInfo: system}).GetInfo().Value; value != "Papirus-Dark" {
+		t.Errorf("icons = %q", value)
+	}
+
+	empty := SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}
+	if result := (&IconsInfo{SystemInfo: empty}).GetInfo(); result.Value != "Unknown" {
+		t.Errorf("icons without gsettings = %q", result.Value)
+	}
+}
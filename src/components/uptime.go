@@ -2,32 +2,43 @@ package components
 
 import (
 	"fmt"
+	"io/fs"
+	"path"
 	"strconv"
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "uptime",
+		Label:   "Uptime",
+		Icon:    "󰔟",
+		Enabled: true,
+		Order:   50,
+		New:     func() InfoProvider { return &UptimeInfo{SystemInfo: SystemInfo{Name: "Uptime"}} },
+	})
+}
+
 // UptimeInfo provides system uptime information
 type UptimeInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the system uptime
-func (u *UptimeInfo) GetInfo() string {
-	out, err := common.GlobalCommandExecutor.Execute("cat", "/proc/uptime")
+func (u *UptimeInfo) GetInfo() Result {
+	data, err := fs.ReadFile(u.rootFS(), path.Join(procDir, "uptime"))
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
 
-	fields := strings.Fields(out)
+	fields := strings.Fields(string(data))
 	if len(fields) < 1 {
-		return "Unknown"
+		return ErrorResult(ErrNotFound)
 	}
 
 	uptime, err := strconv.ParseFloat(fields[0], 64)
 	if err != nil {
-		return "Unknown"
+		return ErrorResult(err)
 	}
 
 	// Convert to seconds
@@ -38,11 +49,18 @@ func (u *UptimeInfo) GetInfo() string {
 	seconds %= 3600
 	minutes := seconds / 60
 
+	result := Fields{
+		"seconds": int(uptime),
+		"days":    days,
+		"hours":   hours,
+		"minutes": minutes,
+	}
+
 	if days > 0 {
-		return fmt.Sprintf("%d days, %d hours, %d minutes", days, hours, minutes)
+		return NewResult(fmt.Sprintf("%d days, %d hours, %d minutes", days, hours, minutes), result)
 	} else if hours > 0 {
-		return fmt.Sprintf("%d hours, %d minutes", hours, minutes)
+		return NewResult(fmt.Sprintf("%d hours, %d minutes", hours, minutes), result)
 	} else {
-		return fmt.Sprintf("%d minutes", minutes)
+		return NewResult(fmt.Sprintf("%d minutes", minutes), result)
 	}
 }
@@ -0,0 +1,34 @@
+package components
+
+import "testing"
+
+func TestUptimeInfo(t *testing.T) {
+	tests := []struct {
+		uptime string
+		want   string
+	}{
+		{"273613.85 1074321.12\n", "3 days, 4 hours, 0 minutes"},
+		{"7265.02 28123.40\n", "2 hours, 1 minutes"},
+		{"59.99 200.01\n", "0 minutes"},
+	}
+	for _, test := range tests {
+		fsys := newFixtureFS(map[string]string{"proc/uptime": test.uptime})
+		info := &UptimeInfo{SystemInfo: SystemInfo{FS: fsys, Exec: newFakeExecutor(nil)}}
+		if result := info.GetInfo(); result.Value != test.want {
+			t.Errorf("%q: Value = %q, want %q", test.uptime, result.Value, test.want)
+		}
+	}
+}
+
+func TestUptimeInfoErrors(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"missing":   nil,
+		"empty":     {"proc/uptime": ""},
+		"malformed": {"proc/uptime": "soon\n"},
+	} {
+		info := &UptimeInfo{SystemInfo: SystemInfo{FS: newFixtureFS(files), Exec: newFakeExecutor(nil)}}
+		if result := info.GetInfo(); result.Err == nil {
+			t.Errorf("%s: expected an error, got %q", name, result.Value)
+		}
+	}
+}
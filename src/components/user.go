@@ -3,25 +3,34 @@ package components
 import (
 	"os"
 	"strings"
-
-	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:      "user",
+		Label:    "User",
+		Icon:     "󰀄",
+		Enabled:  true,
+		Order:    20,
+		Priority: 90,
+		New:      func() InfoProvider { return &UserInfo{SystemInfo: SystemInfo{Name: "User"}} },
+	})
+}
+
 // UserInfo provides user information
 type UserInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the current user
-func (u *UserInfo) GetInfo() string {
+func (u *UserInfo) GetInfo() Result {
 	user := os.Getenv("USER")
 	if user == "" {
-		out, err := common.GlobalCommandExecutor.Execute("whoami")
-		if err == nil {
-			user = strings.TrimSpace(out)
-		} else {
-			user = "Unknown"
+		out, err := u.executor().Execute("whoami")
+		if err != nil {
+			return ErrorResult(err)
 		}
+		user = strings.TrimSpace(out)
 	}
-	return user
+	return NewResult(user, Fields{"name": user})
 }
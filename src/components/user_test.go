@@ -0,0 +1,17 @@
+package components
+
+import "testing"
+
+func TestUserInfo(t *testing.T) {
+	t.Setenv("USER", "luna")
+	info := &UserInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if value := info.GetInfo().Value; value != "luna" {
+		t.Errorf("Value = %q", value)
+	}
+
+	clearEnv(t, "USER")
+	info.Exec = newFakeExecutor(map[string]string{"whoami": "root\n"})
+	if value := info.GetInfo().Value; value != "root" {
+		t.Errorf("whoami: Value = %q", value)
+	}
+}
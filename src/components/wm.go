@@ -6,13 +6,24 @@ import (
 	"lunarfetch/src/common"
 )
 
+func init() {
+	Register(Registration{
+		Key:     "wm",
+		Label:   "WM",
+		Icon:    "󰖲",
+		Enabled: false,
+		Order:   190,
+		New:     func() InfoProvider { return &WMInfo{SystemInfo: SystemInfo{Name: "WM"}} },
+	})
+}
+
 // WMInfo provides window manager information
 type WMInfo struct {
 	SystemInfo
 }
 
 // GetInfo returns the window manager
-func (w *WMInfo) GetInfo() string {
+func (w *WMInfo) GetInfo() Result {
 	// Try to get from environment variable
 	if wm := strings.TrimSpace(strings.ToLower(strings.Join([]string{
 		strings.TrimSpace(strings.ToLower(common.GetEnv("XDG_CURRENT_DESKTOP", ""))),
@@ -20,22 +31,23 @@ func (w *WMInfo) GetInfo() string {
 		strings.TrimSpace(strings.ToLower(common.GetEnv("GDMSESSION", ""))),
 		strings.TrimSpace(strings.ToLower(common.GetEnv("XDG_SESSION_DESKTOP", ""))),
 	}, " "))); wm != "" && wm != " " {
-		return strings.TrimSpace(wm)
+		return NewResult(strings.TrimSpace(wm), Fields{"name": strings.TrimSpace(wm)})
 	}
 
 	// Try to get from wmctrl
-	out, err := common.GlobalCommandExecutor.Execute("wmctrl", "-m")
+	out, err := w.executor().Execute("wmctrl", "-m")
 	if err == nil {
 		lines := strings.Split(out, "\n")
 		for _, line := range lines {
 			if strings.HasPrefix(line, "Name:") {
-				return strings.TrimSpace(strings.TrimPrefix(line, "Name:"))
+				name := strings.TrimSpace(strings.TrimPrefix(line, "Name:"))
+				return NewResult(name, Fields{"name": name})
 			}
 		}
 	}
 
 	// Try to get from ps
-	out, err = common.GlobalCommandExecutor.Execute("ps", "-e")
+	out, err = w.executor().Execute("ps", "-e")
 	if err == nil {
 		lines := strings.Split(out, "\n")
 		for _, line := range lines {
@@ -46,11 +58,11 @@ func (w *WMInfo) GetInfo() string {
 				"scrotwm", "spectrwm", "stumpwm", "subtle", "sway", "wmaker", "wmfs", "wmii", "xfwm4", "xmonad",
 			} {
 				if strings.Contains(line, wm) {
-					return wm
+					return NewResult(wm, Fields{"name": wm})
 				}
 			}
 		}
 	}
 
-	return "Unknown"
+	return ErrorResult(ErrNotFound)
 }
@@ -0,0 +1,32 @@
+package components
+
+import "testing"
+
+// desktopEnv lists the variables the WM and DE components consult
+var desktopEnv = []string{"XDG_CURRENT_DESKTOP", "DESKTOP_SESSION", "GDMSESSION", "XDG_SESSION_DESKTOP"}
+
+func TestWMInfoFromEnvironment(t *testing.T) {
+	clearEnv(t, desktopEnv...)
+	t.Setenv("XDG_CURRENT_DESKTOP", "Hyprland")
+
+	info := &WMInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: newFakeExecutor(nil)}}
+	if value := info.GetInfo().Value; value != "hyprland" {
+		t.Errorf("Value = %q", value)
+	}
+}
+
+func TestWMInfoFromCommands(t *testing.T) {
+	clearEnv(t, desktopEnv...)
+
+	wmctrl := newFakeExecutor(map[string]string{"wmctrl -m": "Name: Openbox\nClass: N/A\nPID: 1234\n"})
+	info := &WMInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: wmctrl}}
+	if value := info.GetInfo().Value; value != "Openbox" {
+		t.Errorf("wmctrl: Value = %q", value)
+	}
+
+	ps := newFakeExecutor(map[string]string{"ps -e": "    PID TTY          TIME CMD\n      1 ?        00:00:02 systemd\n   1042 tty1     00:01:13 bspwm\n"})
+	info = &WMInfo{SystemInfo: SystemInfo{FS: newFixtureFS(nil), Exec: ps}}
+	if value := info.GetInfo().Value; value != "bspwm" {
+		t.Errorf("ps: Value = %q", value)
+	}
+}
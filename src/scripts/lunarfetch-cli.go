@@ -8,6 +8,10 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"lunarfetch/src/common"
+	"lunarfetch/src/components"
+	"lunarfetch/src/utils"
 )
 
 var (
@@ -33,24 +37,12 @@ type Dependency struct {
 }
 
 var dependencies = []Dependency{
-	{
-		Name:        "coreutils",
-		Commands:    []string{"df", "free"},
-		ArchPackage: "coreutils",
-		DebPackage:  "coreutils",
-	},
 	{
 		Name:        "process utilities",
 		Commands:    []string{"uptime"},
 		ArchPackage: "procps-ng",
 		DebPackage:  "procps",
 	},
-	{
-		Name:        "LSB release",
-		Commands:    []string{"lsb_release"},
-		ArchPackage: "lsb-release",
-		DebPackage:  "lsb-release",
-	},
 	{
 		Name:        "display utilities",
 		Commands:    []string{"xrandr", "xdpyinfo", "swaymsg", "wlr-randr"},
@@ -94,7 +86,9 @@ type Info struct {
 	Items   []string `json:"items"`
 }
 
-func HandleCommands(args []string) {
+// HandleCommands runs a subcommand. configPath is the --config given on the
+// command line, empty for the default config.
+func HandleCommands(args []string, configPath string) {
 	if len(args) == 0 {
 		fmt.Printf("%sNo command specified%s\n", ColorRed, ColorReset)
 		PrintUsage()
@@ -120,6 +114,10 @@ func HandleCommands(args []string) {
 		PrintVersion()
 	case "setup-image":
 		SetupImage()
+	case "cache":
+		CacheCommand(args[1:])
+	case "themes":
+		ThemesCommand(args[1:], configPath)
 	default:
 		fmt.Printf("%sUnknown command: %s%s\n", ColorRed, args[0], ColorReset)
 		PrintUsage()
@@ -147,7 +145,10 @@ func printFlagsSection() {
 	fmt.Printf("%sFLAGS:%s\n", ColorYellow, ColorReset)
 	fmt.Printf("  %s-c, --config%s <path>    Specify a custom configuration file path\n", ColorGreen, ColorReset)
 	fmt.Printf("  %s-d, --debug%s            Enable debug mode for verbose output\n", ColorGreen, ColorReset)
+	fmt.Printf("  %s-f, --format%s <fmt>     Print machine-readable output (json, yaml or toml)\n", ColorGreen, ColorReset)
 	fmt.Printf("  %s-h, --help%s             Display this help message\n", ColorGreen, ColorReset)
+	fmt.Printf("  %s-t, --theme%s <name>     Use a color theme (see lunarfetch themes list)\n", ColorGreen, ColorReset)
+	fmt.Printf("  %s--timings%s[=json]       Report where the time went on stderr after the output\n", ColorGreen, ColorReset)
 	fmt.Printf("  %s-v, --version%s          Display version information\n\n", ColorGreen, ColorReset)
 }
 
@@ -184,6 +185,14 @@ func printCommandsSection() {
 	fmt.Printf("                       - Creates necessary directories\n")
 	fmt.Printf("                       - Updates configuration file\n\n")
 
+	fmt.Printf("  %scache%s <clear|show>   Manage the module cache in ~/.cache/lunarfetch\n", ColorGreen, ColorReset)
+	fmt.Printf("                       - show: Lists cached entries with their age and expiry\n")
+	fmt.Printf("                       - clear: Removes all cached entries\n\n")
+
+	fmt.Printf("  %sthemes%s <list|preview> Browse the color themes\n", ColorGreen, ColorReset)
+	fmt.Printf("                       - list: Shows built-in and ~/.config/lunarfetch/themes themes\n")
+	fmt.Printf("                       - preview [name...]: Shows this system's output in each theme\n\n")
+
 	fmt.Printf("  %shelp%s                 Display this help message\n\n", ColorGreen, ColorReset)
 
 	fmt.Printf("  %sversion%s              Display version information\n\n", ColorGreen, ColorReset)
@@ -194,8 +203,12 @@ func printExamplesSection() {
 	fmt.Printf("  lunarfetch                          # Display system information with default config\n")
 	fmt.Printf("  lunarfetch -c ~/.config/lunarfetch/custom.json  # Use custom config file\n")
 	fmt.Printf("  lunarfetch --debug                  # Run with debug output\n")
+	fmt.Printf("  lunarfetch --format json            # Print system information as JSON\n")
+	fmt.Printf("  lunarfetch --timings                # Show which modules are slow\n")
+	fmt.Printf("  lunarfetch --theme nord             # Use a color theme\n")
 	fmt.Printf("  lunarfetch install                  # Install LunarFetch to your system\n")
-	fmt.Printf("  lunarfetch setup-image              # Configure image display\n\n")
+	fmt.Printf("  lunarfetch setup-image              # Configure image display\n")
+	fmt.Printf("  lunarfetch cache clear              # Drop cached module results\n\n")
 }
 
 func printConfigurationSection() {
@@ -338,31 +351,11 @@ func InstallDependencies() {
 		distro = "arch"
 	} else if aptExists != "" || aptGetExists != "" {
 		distro = "debian"
-	} else {
-
-		out, err := exec.Command("lsb_release", "-si").Output()
-		if err == nil {
-			distro = strings.TrimSpace(string(out))
-		} else {
-
-			if _, err := os.Stat("/etc/arch-release"); err == nil {
-				distro = "arch"
-			} else if _, err := os.Stat("/etc/debian_version"); err == nil {
-				distro = "debian"
-			} else if _, err := os.Stat("/etc/os-release"); err == nil {
-
-				osReleaseContent, err := os.ReadFile("/etc/os-release")
-				if err == nil {
-					osRelease := string(osReleaseContent)
-					if strings.Contains(strings.ToLower(osRelease), "arch") {
-						distro = "arch"
-					} else if strings.Contains(strings.ToLower(osRelease), "debian") {
-						distro = "debian"
-					} else if strings.Contains(strings.ToLower(osRelease), "ubuntu") {
-						distro = "ubuntu"
-					}
-				}
-			}
+	} else if release, err := components.HostOSRelease(); err == nil {
+		if release.Is("arch") {
+			distro = "arch"
+		} else if release.Is("debian") || release.Is("ubuntu") {
+			distro = "debian"
 		}
 	}
 
@@ -469,23 +462,6 @@ func CommandExists(cmd string) bool {
 }
 
 func DependencyExists(dep Dependency) bool {
-
-	if dep.Name == "LSB release" {
-
-		if _, err := exec.LookPath("pacman"); err == nil {
-			for _, cmd := range dep.Commands {
-				if cmd == "lsb_release" {
-
-					if _, err := exec.LookPath(cmd); err == nil {
-						return true
-					}
-
-					return false
-				}
-			}
-		}
-	}
-
 	for _, cmd := range dep.Commands {
 		if CommandExists(cmd) {
 			return true
@@ -762,6 +738,12 @@ func Uninstall(purge bool) {
 			fmt.Printf("%sSuccessfully removed configuration directory.%s\n", ColorGreen, ColorReset)
 		}
 
+		cacheDir := common.DefaultCacheDir()
+		fmt.Printf("Removing cache directory: %s\n", cacheDir)
+		if err := os.RemoveAll(cacheDir); err != nil {
+			fmt.Printf("%sWarning: Could not remove cache directory: %s%s\n", ColorYellow, err.Error(), ColorReset)
+		}
+
 		tempDir := filepath.Join(os.TempDir(), "lunarfetch-*")
 		fmt.Printf("Removing temporary files: %s\n", tempDir)
 		cmd = exec.Command("rm", "-rf", tempDir)
@@ -1063,3 +1045,129 @@ func SetupImage() {
 	fmt.Printf("Configuration saved to: %s\n", configFile)
 	fmt.Printf("\nYou can now run %slunarfetch%s to see your system information with the configured image.\n", ColorCyan, ColorReset)
 }
+
+func CacheCommand(args []string) {
+	cache := common.NewDiskCache(common.DefaultCacheDir())
+
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "clear":
+		removed, err := cache.Clear()
+		if err != nil {
+			fmt.Printf("%sError clearing cache: %s%s\n", ColorRed, err.Error(), ColorReset)
+			os.Exit(1)
+		}
+		fmt.Printf("%sRemoved %d cached entries from %s%s\n", ColorGreen, removed, cache.Dir, ColorReset)
+	case "show":
+		entries, err := cache.Entries()
+		if err != nil {
+			fmt.Printf("%sError reading cache: %s%s\n", ColorRed, err.Error(), ColorReset)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Printf("Cache is empty (%s)\n", cache.Dir)
+			return
+		}
+
+		fmt.Printf("%sCache directory:%s %s\n\n", ColorYellow, ColorReset, cache.Dir)
+		now := time.Now()
+		for _, entry := range entries {
+			status := fmt.Sprintf("expires in %s", entry.Expires.Sub(now).Round(time.Minute))
+			if now.After(entry.Expires) {
+				status = ColorYellow + "expired" + ColorReset
+			}
+			fmt.Printf("  %s%-36s%s %8d bytes  cached %s ago, %s\n",
+				ColorGreen, entry.Name, ColorReset, entry.Size, now.Sub(entry.Created).Round(time.Second), status)
+		}
+	default:
+		fmt.Printf("%sUsage: lunarfetch cache <clear|show>%s\n", ColorRed, ColorReset)
+	}
+}
+
+// ThemesCommand lists themes or previews them with the config at configPath
+func ThemesCommand(args []string, configPath string) {
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "list":
+		themes, errs := utils.Themes()
+		for _, err := range errs {
+			fmt.Printf("%sError: %s%s\n", ColorRed, err.Error(), ColorReset)
+		}
+		for _, theme := range themes {
+			source := "built-in"
+			if theme.Path != "" {
+				source = theme.Path
+			}
+			fmt.Printf("  %-22s %s  %s\n", theme.Name, themeSwatch(theme.Colors), source)
+		}
+	case "preview":
+		PreviewThemes(args[1:], configPath)
+	default:
+		fmt.Printf("%sUsage: lunarfetch themes <list|preview [name...]>%s\n", ColorRed, ColorReset)
+	}
+}
+
+// themeSwatch shows a sample line in the theme's colors
+func themeSwatch(colors utils.ColorConfig) string {
+	palette, _ := utils.NewPalette(colors)
+	paint := func(part, text string) string {
+		return palette.Paint("", part).Apply(text)
+	}
+	return paint(utils.ColorBorder, "│") + " " + paint(utils.ColorIcon, "●") + " " +
+		paint(utils.ColorLabel, "Label") + paint(utils.ColorSeparator, ":") + " " + paint(utils.ColorValue, "value") +
+		" " + paint(utils.ColorBorder, "│")
+}
+
+// PreviewThemes prints the system information once per theme, collecting it
+// only once, with the config at configPath or else the default one. Without
+// names every theme is shown.
+func PreviewThemes(names []string, configPath string) {
+	var themes []utils.Theme
+	if len(names) == 0 {
+		var errs []error
+		themes, errs = utils.Themes()
+		for _, err := range errs {
+			fmt.Printf("%sError: %s%s\n", ColorRed, err.Error(), ColorReset)
+		}
+	}
+	for _, name := range names {
+		theme, err := utils.LoadTheme(name)
+		if err != nil {
+			fmt.Printf("%sError: %s%s\n", ColorRed, err.Error(), ColorReset)
+			os.Exit(1)
+		}
+		themes = append(themes, theme)
+	}
+
+	config, err := utils.NewConfigLoader().LoadConfig(configPath)
+	if err != nil {
+		if configPath != "" {
+			fmt.Fprintln(os.Stderr, "Error loading config from", configPath, ":", err)
+		}
+		config = utils.DefaultConfig()
+	}
+
+	displayManager := utils.NewDisplayManager(config)
+	displayManager.InitializeComponents()
+	displayManager.GetInfoParallel()
+
+	for _, theme := range themes {
+		colors := theme.Colors
+		if colors.Depth == "" {
+			colors.Depth = config.Colors.Depth
+		}
+		displayManager.SetColors(colors)
+
+		fmt.Printf("%s%s%s\n", ColorYellow, theme.Name, ColorReset)
+		fmt.Print(displayManager.Redisplay())
+		fmt.Println()
+	}
+}
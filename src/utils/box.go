@@ -1,10 +1,96 @@
 package utils
 
 import (
-	"fmt"
+	"sort"
 	"strings"
 )
 
+// Alignment of a title or footer in the edge of a box
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// DefaultBoxPreset is the box style used unless another one is configured
+const DefaultBoxPreset = "rounded"
+
+// boxPresets are the box styles the preset decoration can name
+var boxPresets = map[string]BoxConfig{
+	"rounded": {
+		TopLeft: "╭", TopRight: "╮", BottomLeft: "╰", BottomRight: "╯",
+		TopEdge: "─", BottomEdge: "─", LeftEdge: "│", RightEdge: "│",
+	},
+	"sharp": {
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		TopEdge: "─", BottomEdge: "─", LeftEdge: "│", RightEdge: "│",
+	},
+	"double": {
+		TopLeft: "╔", TopRight: "╗", BottomLeft: "╚", BottomRight: "╝",
+		TopEdge: "═", BottomEdge: "═", LeftEdge: "║", RightEdge: "║",
+	},
+	"heavy": {
+		TopLeft: "┏", TopRight: "┓", BottomLeft: "┗", BottomRight: "┛",
+		TopEdge: "━", BottomEdge: "━", LeftEdge: "┃", RightEdge: "┃",
+	},
+	"ascii": {
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		TopEdge: "-", BottomEdge: "-", LeftEdge: "|", RightEdge: "|",
+	},
+	"dashed": {
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		TopEdge: "╌", BottomEdge: "╌", LeftEdge: "╎", RightEdge: "╎",
+	},
+	// No border and no padding, for output that is piped or pasted
+	"none": {Padding: &Spacing{}},
+}
+
+// BoxPreset returns the characters of a named box style
+func BoxPreset(name string) (BoxConfig, bool) {
+	preset, ok := boxPresets[name]
+	return preset, ok
+}
+
+// BoxPresets returns the names of the box styles, sorted
+func BoxPresets() []string {
+	names := make([]string, 0, len(boxPresets))
+	for name := range boxPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Spacing is room around content: lines above and below, cells left and
+// right
+type Spacing struct {
+	Top, Right, Bottom, Left int
+}
+
+// DefaultPadding is the room between a box and its content
+var DefaultPadding = Spacing{Right: 1, Left: 1}
+
+// NewSpacing reads spacing the way CSS does: one value for every side, two
+// for top and bottom then left and right, three for top, left and right, and
+// bottom, or four for top, right, bottom and left
+func NewSpacing(values ...int) Spacing {
+	side := func(i int) int {
+		return max(values[i], 0)
+	}
+	switch len(values) {
+	case 0:
+		return Spacing{}
+	case 1:
+		return Spacing{side(0), side(0), side(0), side(0)}
+	case 2:
+		return Spacing{side(0), side(1), side(0), side(1)}
+	case 3:
+		return Spacing{side(0), side(1), side(2), side(1)}
+	default:
+		return Spacing{side(0), side(1), side(2), side(3)}
+	}
+}
+
 type BoxConfig struct {
 	TopLeft     string
 	TopRight    string
@@ -15,6 +101,34 @@ type BoxConfig struct {
 	LeftEdge    string
 	RightEdge   string
 	Separator   string
+	// Border colors the box characters
+	Border Paint
+	// Title and Footer are shown in the top and bottom edges, aligned left
+	// unless TitleAlign or FooterAlign say otherwise
+	Title       string
+	TitleAlign  string
+	Footer      string
+	FooterAlign string
+	// Padding is the room inside the box; nil leaves one cell left and right
+	Padding *Spacing
+	// MinWidth is the narrowest the inside of the box may be, so boxes
+	// stacked on top of each other can line up
+	MinWidth int
+}
+
+// padding returns the room inside the box
+func (c BoxConfig) padding() Spacing {
+	if c.Padding == nil {
+		return DefaultPadding
+	}
+	return *c.Padding
+}
+
+// Overhead is the number of cells the box adds left and right of its
+// content
+func (c BoxConfig) Overhead() int {
+	padding := c.padding()
+	return DisplayWidth(c.LeftEdge) + DisplayWidth(c.RightEdge) + padding.Left + padding.Right
 }
 
 type BoxDrawer struct {
@@ -27,30 +141,87 @@ func NewBoxDrawer(config BoxConfig) *BoxDrawer {
 	}
 }
 
+// innerWidth returns the number of cells between the left and right edges
+// for content
+func (b *BoxDrawer) innerWidth(lines []string) int {
+	padding := b.Config.padding()
+	width := max(MaxWidth(lines), b.Config.MinWidth) + padding.Left + padding.Right
+	// A title or footer takes its width, a space either side and at least
+	// one edge character
+	for _, label := range []string{b.Config.Title, b.Config.Footer} {
+		if label != "" {
+			width = max(width, DisplayWidth(label)+3)
+		}
+	}
+	return width
+}
+
+// Width returns the number of cells the box around content is wide
+func (b *BoxDrawer) Width(content string) int {
+	return b.innerWidth(strings.Split(content, "\n")) + DisplayWidth(b.Config.LeftEdge) + DisplayWidth(b.Config.RightEdge)
+}
+
 func (b *BoxDrawer) Draw(content string) string {
 	lines := strings.Split(content, "\n")
-	maxLen := 0
+	padding := b.Config.padding()
+	width := b.innerWidth(lines)
+	maxLen := width - padding.Left - padding.Right
+
+	border := b.Config.Border
+	left := border.Apply(b.Config.LeftEdge) + strings.Repeat(" ", padding.Left)
+	right := strings.Repeat(" ", padding.Right) + border.Apply(b.Config.RightEdge)
+	blank := PadRight("", maxLen)
+
+	var rows []string
+	if top := edgeLine(b.Config.TopLeft, b.Config.TopEdge, b.Config.TopRight, b.Config.Title, b.Config.TitleAlign, width, border); top != "" {
+		rows = append(rows, top)
+	}
+	for range padding.Top {
+		rows = append(rows, left+blank+right)
+	}
 	for _, line := range lines {
-		if len(line) > maxLen {
-			maxLen = len(line)
-		}
+		rows = append(rows, left+PadRight(line, maxLen)+right)
+	}
+	for range padding.Bottom {
+		rows = append(rows, left+blank+right)
+	}
+	if bottom := edgeLine(b.Config.BottomLeft, b.Config.BottomEdge, b.Config.BottomRight, b.Config.Footer, b.Config.FooterAlign, width, border); bottom != "" {
+		rows = append(rows, bottom)
 	}
 
-	var box strings.Builder
+	// Without a right edge the padding would only leave trailing spaces
+	if b.Config.RightEdge == "" {
+		for i, row := range rows {
+			rows[i] = strings.TrimRight(row, " ")
+		}
+	}
 
-	box.WriteString(b.Config.TopLeft)
-	box.WriteString(strings.Repeat(b.Config.TopEdge, maxLen+2))
-	box.WriteString(b.Config.TopRight + "\n")
+	return strings.Join(rows, "\n") + "\n"
+}
 
-	for _, line := range lines {
-		box.WriteString(b.Config.LeftEdge + " ")
-		box.WriteString(fmt.Sprintf("%-*s", maxLen, line))
-		box.WriteString(" " + b.Config.RightEdge + "\n")
+// edgeLine draws the top or bottom edge of a box, width cells between its
+// corners, with label set in it. A box without this edge only gets the
+// label, or no line at all.
+func edgeLine(leftCorner, edge, rightCorner, label, align string, width int, border Paint) string {
+	if label == "" {
+		if leftCorner+edge+rightCorner == "" {
+			return ""
+		}
+		return border.Apply(leftCorner + strings.Repeat(edge, width) + rightCorner)
 	}
 
-	box.WriteString(b.Config.BottomLeft)
-	box.WriteString(strings.Repeat(b.Config.BottomEdge, maxLen+2))
-	box.WriteString(b.Config.BottomRight + "\n")
+	free := width - DisplayWidth(label) - 2
+	before := 1
+	switch align {
+	case AlignCenter:
+		before = free / 2
+	case AlignRight:
+		before = free - 1
+	}
 
-	return box.String()
+	if edge == "" {
+		return border.Apply(leftCorner) + strings.Repeat(" ", before+1) + label
+	}
+	return border.Apply(leftCorner+strings.Repeat(edge, before)) + " " + label + " " +
+		border.Apply(strings.Repeat(edge, free-before)+rightCorner)
 }
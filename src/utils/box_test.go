@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoxPresets(t *testing.T) {
+	names := BoxPresets()
+	if want := "ascii dashed double heavy none rounded sharp"; strings.Join(names, " ") != want {
+		t.Errorf("BoxPresets() = %v, want %s", names, want)
+	}
+
+	for _, name := range names {
+		preset, _ := BoxPreset(name)
+		box := strings.TrimRight(NewBoxDrawer(preset).Draw("日本\nab"), "\n")
+		lines := strings.Split(box, "\n")
+		for _, line := range lines {
+			if name != "none" && DisplayWidth(line) != DisplayWidth(lines[0]) {
+				t.Errorf("%s: line %q is %d cells wide", name, line, DisplayWidth(line))
+			}
+		}
+		if name == "none" && box != "日本\nab" {
+			t.Errorf("none = %q", box)
+		}
+	}
+}
+
+func TestBoxTitleAndFooterAlign(t *testing.T) {
+	ascii, _ := BoxPreset("ascii")
+	ascii.Title = "T"
+	ascii.TitleAlign = AlignCenter
+	ascii.Footer = "F"
+	ascii.FooterAlign = AlignRight
+	box := NewBoxDrawer(ascii).Draw("content")
+
+	want := "+--- T ---+\n| content |\n+----- F -+\n"
+	if box != want {
+		t.Errorf("got\n%s\nwant\n%s", box, want)
+	}
+
+	// Without a border the title is all that is left of the edge
+	none, _ := BoxPreset("none")
+	none.Title = "T"
+	none.TitleAlign = AlignRight
+	if box := NewBoxDrawer(none).Draw("content"); box != "    T\ncontent\n" {
+		t.Errorf("got %q", box)
+	}
+}
+
+func TestBoxPadding(t *testing.T) {
+	ascii, _ := BoxPreset("ascii")
+	padding := NewSpacing(1, 2, 0)
+	ascii.Padding = &padding
+	box := NewBoxDrawer(ascii).Draw("ab")
+
+	want := "+------+\n|      |\n|  ab  |\n+------+\n"
+	if box != want {
+		t.Errorf("got\n%s\nwant\n%s", box, want)
+	}
+	if ascii.Overhead() != 6 {
+		t.Errorf("Overhead() = %d", ascii.Overhead())
+	}
+}
+
+func TestNewSpacing(t *testing.T) {
+	tests := []struct {
+		values []int
+		want   Spacing
+	}{
+		{nil, Spacing{}},
+		{[]int{1}, Spacing{1, 1, 1, 1}},
+		{[]int{1, 2}, Spacing{1, 2, 1, 2}},
+		{[]int{1, 2, 3}, Spacing{1, 2, 3, 2}},
+		{[]int{1, 2, 3, 4}, Spacing{1, 2, 3, 4}},
+		{[]int{-1, 2}, Spacing{0, 2, 0, 2}},
+	}
+	for _, test := range tests {
+		if got := NewSpacing(test.values...); got != test.want {
+			t.Errorf("NewSpacing(%v) = %+v, want %+v", test.values, got, test.want)
+		}
+	}
+}
+
+func TestDecorationsPreset(t *testing.T) {
+	d := NewDisplayManager(DefaultConfig())
+
+	box := d.boxConfig(Decorations{Preset: "double", TopLeft: "*"})
+	if box.TopLeft != "*" || box.TopRight != "╗" || box.LeftEdge != "║" {
+		t.Errorf("double with a custom corner = %+v", box)
+	}
+	if box := d.boxConfig(Decorations{Preset: "missing"}); box.TopLeft != "╭" {
+		t.Errorf("unknown preset = %+v", box)
+	}
+
+	// A group's own preset replaces the characters set for every box
+	base := Decorations{Preset: "rounded", LeftEdge: "!", Padding: []int{0, 2}, Margin: []int{1}, Title: "main"}
+	group := mergeDecorations(base, Decorations{Preset: "heavy"})
+	if group.LeftEdge != "" || group.Margin != nil || group.Title != "" || len(group.Padding) != 2 {
+		t.Errorf("group = %+v", group)
+	}
+	if group := mergeDecorations(base, Decorations{TopEdge: "="}); group.LeftEdge != "!" || group.TopEdge != "=" {
+		t.Errorf("group = %+v", group)
+	}
+}
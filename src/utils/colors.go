@@ -0,0 +1,383 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorDepth is the number of colors the terminal can show
+type ColorDepth int
+
+const (
+	ColorsNone ColorDepth = iota
+	Colors16
+	Colors256
+	ColorsTrue
+)
+
+// Parts of the output that can be colored
+const (
+	ColorBorder    = "border"
+	ColorIcon      = "icon"
+	ColorLabel     = "label"
+	ColorSeparator = "separator"
+	ColorValue     = "value"
+)
+
+const ansiReset = "\033[0m"
+
+// ColorScheme holds a color spec for each part of a module line. A spec is
+// a color name, a 256-color index or #RRGGBB, optionally preceded by
+// bold, dim, italic or underline, e.g. "bold #89b4fa".
+type ColorScheme struct {
+	Border    string `json:"border,omitempty"`
+	Icon      string `json:"icon,omitempty"`
+	Label     string `json:"label,omitempty"`
+	Separator string `json:"separator,omitempty"`
+	Value     string `json:"value,omitempty"`
+}
+
+type ColorConfig struct {
+	ColorScheme
+	// Depth forces "none", "16", "256" or "truecolor" instead of detecting it
+	Depth string `json:"depth,omitempty"`
+	// Modules overrides the scheme for single modules
+	Modules map[string]ColorScheme `json:"modules,omitempty"`
+}
+
+func (s ColorScheme) spec(part string) string {
+	switch part {
+	case ColorBorder:
+		return s.Border
+	case ColorIcon:
+		return s.Icon
+	case ColorLabel:
+		return s.Label
+	case ColorSeparator:
+		return s.Separator
+	case ColorValue:
+		return s.Value
+	}
+	return ""
+}
+
+// Paint is a resolved style; the zero Paint leaves text unchanged
+type Paint struct {
+	start string
+}
+
+// Apply wraps text in the style's escape sequences
+func (p Paint) Apply(text string) string {
+	if p.start == "" || text == "" {
+		return text
+	}
+	return p.start + text + ansiReset
+}
+
+var basicColors = map[string]int{
+	"black":   0,
+	"red":     1,
+	"green":   2,
+	"yellow":  3,
+	"blue":    4,
+	"magenta": 5,
+	"cyan":    6,
+	"white":   7,
+	"gray":    8,
+	"grey":    8,
+}
+
+var attributes = map[string]int{
+	"bold":      1,
+	"dim":       2,
+	"italic":    3,
+	"underline": 4,
+}
+
+// ansiColor is a parsed color: an index into the 256-color palette, of which
+// the first 16 are the terminal's own colors, or a truecolor value
+type ansiColor struct {
+	index   int
+	rgb     bool
+	r, g, b int
+}
+
+type style struct {
+	attributes []int
+	color      *ansiColor
+}
+
+func parseColor(word string) (*ansiColor, error) {
+	name := strings.NewReplacer("-", "", "_", "").Replace(word)
+	if bright, ok := strings.CutPrefix(name, "bright"); ok {
+		if index, ok := basicColors[bright]; ok && index < 8 {
+			return &ansiColor{index: index + 8}, nil
+		}
+	}
+	if index, ok := basicColors[name]; ok {
+		return &ansiColor{index: index}, nil
+	}
+
+	if hex, ok := strings.CutPrefix(word, "#"); ok {
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, fmt.Errorf("invalid color %q", word)
+		}
+		return &ansiColor{rgb: true, r: int(value >> 16 & 0xff), g: int(value >> 8 & 0xff), b: int(value & 0xff)}, nil
+	}
+
+	index, err := strconv.Atoi(word)
+	if err != nil || index < 0 || index > 255 {
+		return nil, fmt.Errorf("invalid color %q", word)
+	}
+	return &ansiColor{index: index}, nil
+}
+
+func parseStyle(spec string) (style, error) {
+	var parsed style
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if code, ok := attributes[word]; ok {
+			parsed.attributes = append(parsed.attributes, code)
+			continue
+		}
+		if word == "default" || word == "none" {
+			continue
+		}
+		if parsed.color != nil {
+			return style{}, fmt.Errorf("more than one color in %q", spec)
+		}
+		color, err := parseColor(word)
+		if err != nil {
+			return style{}, err
+		}
+		parsed.color = color
+	}
+	return parsed, nil
+}
+
+// ParseColor checks a color spec and resolves it for the given depth
+func ParseColor(spec string, depth ColorDepth) (Paint, error) {
+	parsed, err := parseStyle(spec)
+	if err != nil {
+		return Paint{}, err
+	}
+	if depth == ColorsNone {
+		return Paint{}, nil
+	}
+
+	var codes []string
+	for _, attribute := range parsed.attributes {
+		codes = append(codes, strconv.Itoa(attribute))
+	}
+	if parsed.color != nil {
+		codes = append(codes, parsed.color.sgr(depth))
+	}
+	if len(codes) == 0 {
+		return Paint{}, nil
+	}
+	return Paint{start: "\033[" + strings.Join(codes, ";") + "m"}, nil
+}
+
+// sgr returns the foreground parameter for c, downgraded to what depth
+// can show
+func (c *ansiColor) sgr(depth ColorDepth) string {
+	if c.rgb && depth == ColorsTrue {
+		return fmt.Sprintf("38;2;%d;%d;%d", c.r, c.g, c.b)
+	}
+
+	index := c.index
+	if c.rgb {
+		index = nearest256(c.r, c.g, c.b)
+	}
+	if index >= 16 && depth < Colors256 {
+		r, g, b := paletteRGB(index)
+		index = nearest16(r, g, b)
+	}
+
+	switch {
+	case index < 8:
+		return strconv.Itoa(30 + index)
+	case index < 16:
+		return strconv.Itoa(90 + index - 8)
+	default:
+		return "38;5;" + strconv.Itoa(index)
+	}
+}
+
+// The xterm colors the 16 basic colors usually map to
+var basicRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func paletteRGB(index int) (int, int, int) {
+	switch {
+	case index < 16:
+		return basicRGB[index][0], basicRGB[index][1], basicRGB[index][2]
+	case index < 232:
+		index -= 16
+		return cubeLevels[index/36], cubeLevels[index/6%6], cubeLevels[index%6]
+	default:
+		level := 8 + (index-232)*10
+		return level, level, level
+	}
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return dr*dr + dg*dg + db*db
+}
+
+func nearestLevel(value int) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(value-level) < abs(value-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// nearest256 maps a truecolor value to the closest entry of the color cube
+// or the grey ramp, leaving out the basic colors terminals redefine
+func nearest256(r, g, b int) int {
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := 16 + 36*ri + 6*gi + bi
+
+	grey := (r + g + b) / 3
+	greyIndex := 232 + min(max((grey-8+5)/10, 0), 23)
+
+	cr, cg, cb := paletteRGB(cube)
+	gr, gg, gb := paletteRGB(greyIndex)
+	if colorDistance(r, g, b, gr, gg, gb) < colorDistance(r, g, b, cr, cg, cb) {
+		return greyIndex
+	}
+	return cube
+}
+
+func nearest16(r, g, b int) int {
+	best, bestDistance := 0, -1
+	for index := range basicRGB {
+		pr, pg, pb := paletteRGB(index)
+		if distance := colorDistance(r, g, b, pr, pg, pb); bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = index, distance
+		}
+	}
+	return best
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// stdoutIsTerminal is replaced in tests, which never run on a terminal
+var stdoutIsTerminal = func() bool {
+	return isTerminal(os.Stdout)
+}
+
+// DetectColorDepth works out the color support from the environment,
+// following https://no-color.org for NO_COLOR. Output that is piped or
+// redirected gets no colors.
+func DetectColorDepth() ColorDepth {
+	if os.Getenv("NO_COLOR") != "" || !stdoutIsTerminal() {
+		return ColorsNone
+	}
+
+	colorterm := strings.ToLower(os.Getenv("COLORTERM"))
+	if colorterm == "truecolor" || colorterm == "24bit" {
+		return ColorsTrue
+	}
+
+	term := os.Getenv("TERM")
+	switch {
+	case term == "dumb":
+		return ColorsNone
+	case strings.Contains(term, "direct"):
+		return ColorsTrue
+	case strings.Contains(term, "256color"):
+		return Colors256
+	}
+	return Colors16
+}
+
+func parseColorDepth(depth string) (ColorDepth, bool) {
+	switch strings.ToLower(depth) {
+	case "none":
+		return ColorsNone, true
+	case "16":
+		return Colors16, true
+	case "256":
+		return Colors256, true
+	case "truecolor", "24bit":
+		return ColorsTrue, true
+	}
+	return ColorsNone, false
+}
+
+// Palette holds the resolved colors for the output
+type Palette struct {
+	Depth   ColorDepth
+	base    map[string]Paint
+	modules map[string]map[string]Paint
+}
+
+var colorParts = []string{ColorBorder, ColorIcon, ColorLabel, ColorSeparator, ColorValue}
+
+// NewPalette resolves the color config for the terminal. A configured depth
+// applies even when the output is not a terminal, but NO_COLOR wins over
+// it; invalid specs are reported and left uncolored.
+func NewPalette(config ColorConfig) (*Palette, []error) {
+	depth := DetectColorDepth()
+	if forced, ok := parseColorDepth(config.Depth); ok && os.Getenv("NO_COLOR") == "" {
+		depth = forced
+	}
+
+	var errs []error
+	resolve := func(scheme ColorScheme, name string) map[string]Paint {
+		paints := make(map[string]Paint)
+		for _, part := range colorParts {
+			spec := scheme.spec(part)
+			if spec == "" {
+				continue
+			}
+			paint, err := ParseColor(spec, depth)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("colors%s.%s: %w", name, part, err))
+				continue
+			}
+			paints[part] = paint
+		}
+		return paints
+	}
+
+	palette := &Palette{
+		Depth:   depth,
+		base:    resolve(config.ColorScheme, ""),
+		modules: make(map[string]map[string]Paint),
+	}
+	for module, scheme := range config.Modules {
+		palette.modules[module] = resolve(scheme, ".modules."+module)
+	}
+	return palette, errs
+}
+
+// Paint returns the paint for a part of a module's line, falling back to
+// the global scheme. A nil Palette paints nothing.
+func (p *Palette) Paint(module, part string) Paint {
+	if p == nil {
+		return Paint{}
+	}
+	if paint, ok := p.modules[module][part]; ok {
+		return paint
+	}
+	return p.base[part]
+}
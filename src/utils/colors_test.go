@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		spec  string
+		depth ColorDepth
+		want  string
+	}{
+		{"red", Colors16, "\033[31m"},
+		{"bright-blue", Colors16, "\033[94m"},
+		{"grey", ColorsTrue, "\033[90m"},
+		{"bold cyan", Colors256, "\033[1;36m"},
+		{"208", Colors256, "\033[38;5;208m"},
+		{"#89b4fa", ColorsTrue, "\033[38;2;137;180;250m"},
+		// Downgraded to the nearest entry the terminal can show
+		{"#89b4fa", Colors256, "\033[38;5;111m"},
+		{"#808080", Colors256, "\033[38;5;244m"},
+		{"#89b4fa", Colors16, "\033[94m"},
+		{"196", Colors16, "\033[91m"},
+		{"bold #89b4fa", ColorsNone, ""},
+		{"default", ColorsTrue, ""},
+	}
+
+	for _, test := range tests {
+		paint, err := ParseColor(test.spec, test.depth)
+		if err != nil {
+			t.Errorf("ParseColor(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if paint.start != test.want {
+			t.Errorf("ParseColor(%q, %d) = %q, want %q", test.spec, test.depth, paint.start, test.want)
+		}
+	}
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	for _, spec := range []string{"#12345", "#gggggg", "256", "-1", "purple", "red blue"} {
+		if _, err := ParseColor(spec, ColorsTrue); err == nil {
+			t.Errorf("ParseColor(%q) succeeded", spec)
+		}
+	}
+}
+
+// useTerminal makes stdout count as a terminal, or not, during a test
+func useTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	previous := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdoutIsTerminal = previous })
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	useTerminal(t, true)
+	tests := []struct {
+		noColor, colorterm, term string
+		want                     ColorDepth
+	}{
+		{"", "truecolor", "xterm-256color", ColorsTrue},
+		{"", "24bit", "", ColorsTrue},
+		{"", "", "xterm-direct", ColorsTrue},
+		{"", "", "xterm-256color", Colors256},
+		{"", "", "linux", Colors16},
+		{"", "", "dumb", ColorsNone},
+		{"1", "truecolor", "xterm-256color", ColorsNone},
+	}
+
+	for _, test := range tests {
+		t.Setenv("NO_COLOR", test.noColor)
+		t.Setenv("COLORTERM", test.colorterm)
+		t.Setenv("TERM", test.term)
+		if got := DetectColorDepth(); got != test.want {
+			t.Errorf("DetectColorDepth(%+v) = %d", test, got)
+		}
+	}
+}
+
+func TestPaletteModuleOverrides(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	config := ColorConfig{
+		ColorScheme: ColorScheme{Label: "blue", Value: "white"},
+		Depth:       "16",
+		Modules:     map[string]ColorScheme{"kernel": {Value: "red"}, "cpu": {Label: "nonsense"}},
+	}
+
+	palette, errs := NewPalette(config)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "colors.modules.cpu.label") {
+		t.Errorf("errs = %v", errs)
+	}
+	if got := palette.Paint("kernel", ColorValue).Apply("6.10"); got != "\033[31m6.10\033[0m" {
+		t.Errorf("kernel value = %q", got)
+	}
+	if got := palette.Paint("kernel", ColorLabel).Apply("Kernel"); got != "\033[34mKernel\033[0m" {
+		t.Errorf("kernel label = %q", got)
+	}
+	if got := palette.Paint("cpu", ColorIcon).Apply("x"); got != "x" {
+		t.Errorf("unset icon = %q", got)
+	}
+}
+
+func TestDetectColorDepthWithoutTerminal(t *testing.T) {
+	useTerminal(t, false)
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("COLORTERM", "truecolor")
+	t.Setenv("TERM", "xterm-256color")
+	if got := DetectColorDepth(); got != ColorsNone {
+		t.Errorf("DetectColorDepth() = %d for piped output", got)
+	}
+
+	// A forced depth still colors piped output
+	palette, _ := NewPalette(ColorConfig{ColorScheme: ColorScheme{Value: "red"}, Depth: "16"})
+	if got := palette.Paint("kernel", ColorValue).Apply("6.10"); got != "\033[31m6.10\033[0m" {
+		t.Errorf("forced value = %q", got)
+	}
+	palette, _ = NewPalette(ColorConfig{ColorScheme: ColorScheme{Value: "red"}})
+	if got := palette.Paint("kernel", ColorValue).Apply("6.10"); got != "6.10" {
+		t.Errorf("detected value = %q", got)
+	}
+}
+
+func TestPaletteRespectsNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	palette, _ := NewPalette(ColorConfig{ColorScheme: ColorScheme{Value: "red"}, Depth: "truecolor"})
+	if got := palette.Paint("kernel", ColorValue).Apply("6.10"); got != "6.10" {
+		t.Errorf("value = %q", got)
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	text := "\033[1;38;2;137;180;250mOS\033[0m: \033]8;;https://archlinux.org\033\\Arch\033]8;;\033\\"
+	if got := StripANSI(text); got != "OS: Arch" {
+		t.Errorf("StripANSI = %q", got)
+	}
+}
+
+func TestBoxDrawerPadsColoredLines(t *testing.T) {
+	red, _ := ParseColor("red", Colors16)
+	box := NewBoxDrawer(BoxConfig{
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		TopEdge: "-", BottomEdge: "-", LeftEdge: "|", RightEdge: "|",
+	}).Draw(red.Apply("red") + "\nplain text")
+
+	want := "+------------+\n| \033[31mred\033[0m        |\n| plain text |\n+------------+\n"
+	if box != want {
+		t.Errorf("box =\n%s\nwant\n%s", box, want)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"lunarfetch/src/components"
 )
 
 const (
@@ -11,20 +13,47 @@ const (
 	DefaultConfigFile = "config.json"
 	DefaultLogoPath   = "~/.config/lunarfetch/logos"
 	DefaultImagePath  = "~/.config/lunarfetch/images"
+	DefaultThemeDir   = "~/.config/lunarfetch/themes"
+
+	DefaultCommandTimeoutMs = 2000
+	DefaultFetchTimeoutMs   = 5000
 )
 
+// Decorations are the characters boxes and separator lines are drawn with
+type Decorations struct {
+	// Preset names a box style; the characters set below replace its own
+	Preset      string `json:"preset"`
+	TopLeft     string `json:"topLeft"`
+	TopRight    string `json:"topRight"`
+	BottomLeft  string `json:"bottomLeft"`
+	BottomRight string `json:"bottomRight"`
+	TopEdge     string `json:"topEdge"`
+	BottomEdge  string `json:"bottomEdge"`
+	LeftEdge    string `json:"leftEdge"`
+	RightEdge   string `json:"rightEdge"`
+	Separator   string `json:"separator"`
+	// Title and Footer are set in the top and bottom edges of the box
+	Title       string `json:"title"`
+	TitleAlign  string `json:"titleAlign"`
+	Footer      string `json:"footer"`
+	FooterAlign string `json:"footerAlign"`
+	// Padding is the room inside the box and Margin the room around it, as
+	// one to four numbers like in CSS
+	Padding []int `json:"padding"`
+	Margin  []int `json:"margin"`
+}
+
+// Group is a titled section of the info panel with its own layout
+type Group struct {
+	Title  string   `json:"title"`
+	Style  string   `json:"style"`
+	Layout []string `json:"layout"`
+	// Decorations override the characters set for every box
+	Decorations Decorations `json:"decorations"`
+}
+
 type Config struct {
-	Decorations struct {
-		TopLeft     string `json:"topLeft"`
-		TopRight    string `json:"topRight"`
-		BottomLeft  string `json:"bottomLeft"`
-		BottomRight string `json:"bottomRight"`
-		TopEdge     string `json:"topEdge"`
-		BottomEdge  string `json:"bottomEdge"`
-		LeftEdge    string `json:"leftEdge"`
-		RightEdge   string `json:"rightEdge"`
-		Separator   string `json:"separator"`
-	} `json:"decorations"`
+	Decorations Decorations `json:"decorations"`
 
 	Logo struct {
 		EnableLogo bool   `json:"enableLogo"`
@@ -51,54 +80,44 @@ type Config struct {
 		Offset         int    `json:"offset"`
 		Background     string `json:"background"`
 		Position       string `json:"position"`
+		Location       string `json:"location"`
 	} `json:"image"`
 
 	Display struct {
 		ShowLogoFirst  bool `json:"showLogoFirst"`
 		ShowImageFirst bool `json:"showImageFirst"`
+		Gap            int  `json:"gap"`
+		// Groups is how groups are arranged: stacked or in columns
+		Groups string `json:"groups"`
 	} `json:"display"`
 
-	Icons struct {
-		Host       string `json:"host"`
-		User       string `json:"user"`
-		OS         string `json:"os"`
-		Kernel     string `json:"kernel"`
-		Uptime     string `json:"uptime"`
-		Terminal   string `json:"terminal"`
-		Shell      string `json:"shell"`
-		Disk       string `json:"disk"`
-		Memory     string `json:"memory"`
-		Packages   string `json:"packages"`
-		Battery    string `json:"battery"`
-		GPU        string `json:"gpu"`
-		CPU        string `json:"cpu"`
-		Resolution string `json:"resolution"`
-		DE         string `json:"de"`
-		WMTheme    string `json:"wm_theme"`
-		Theme      string `json:"theme"`
-		Icons      string `json:"icons"`
-	} `json:"icons"`
-
-	Modules struct {
-		ShowUser       bool `json:"show_user"`
-		ShowCPU        bool `json:"show_cpu"`
-		ShowGPU        bool `json:"show_gpu"`
-		ShowUptime     bool `json:"show_uptime"`
-		ShowShell      bool `json:"show_shell"`
-		ShowMemory     bool `json:"show_memory"`
-		ShowPackages   bool `json:"show_packages"`
-		ShowOS         bool `json:"show_os"`
-		ShowHost       bool `json:"show_host"`
-		ShowKernel     bool `json:"show_kernel"`
-		ShowBattery    bool `json:"show_battery"`
-		ShowDisk       bool `json:"show_disk"`
-		ShowResolution bool `json:"show_resolution"`
-		ShowDE         bool `json:"show_de"`
-		ShowWMTheme    bool `json:"show_wm_theme"`
-		ShowTheme      bool `json:"show_theme"`
-		ShowIcons      bool `json:"show_icons"`
-		ShowTerminal   bool `json:"show_terminal"`
-	} `json:"modules"`
+	// Fit shrinks the output to the terminal unless disabled; Priority
+	// overrides which modules are hidden first when it is too short
+	Fit struct {
+		Disabled bool           `json:"disabled"`
+		Priority map[string]int `json:"priority"`
+	} `json:"fit"`
+
+	Timeouts struct {
+		Command int `json:"command"`
+		Fetch   int `json:"fetch"`
+	} `json:"timeouts"`
+
+	Cache struct {
+		Enabled bool           `json:"enabled"`
+		TTL     map[string]int `json:"ttl"`
+	} `json:"cache"`
+
+	// Theme names a built-in or user theme the colors are based on
+	Theme  string      `json:"theme"`
+	Colors ColorConfig `json:"colors"`
+
+	Icons   map[string]string `json:"icons"`
+	Modules map[string]bool   `json:"modules"`
+	Layout  []string          `json:"layout"`
+	Groups  []Group           `json:"groups"`
+
+	Options map[string]json.RawMessage `json:"options"`
 }
 
 type ConfigLoader struct{}
@@ -143,29 +162,8 @@ func (c *ConfigLoader) LoadConfig(paths ...string) (Config, error) {
 }
 
 func applyConfigDefaults(config Config) Config {
-	if config.Decorations.TopLeft == "" {
-		config.Decorations.TopLeft = "╭"
-	}
-	if config.Decorations.TopRight == "" {
-		config.Decorations.TopRight = "╮"
-	}
-	if config.Decorations.BottomLeft == "" {
-		config.Decorations.BottomLeft = "╰"
-	}
-	if config.Decorations.BottomRight == "" {
-		config.Decorations.BottomRight = "╯"
-	}
-	if config.Decorations.TopEdge == "" {
-		config.Decorations.TopEdge = "─"
-	}
-	if config.Decorations.BottomEdge == "" {
-		config.Decorations.BottomEdge = "─"
-	}
-	if config.Decorations.LeftEdge == "" {
-		config.Decorations.LeftEdge = "│"
-	}
-	if config.Decorations.RightEdge == "" {
-		config.Decorations.RightEdge = "│"
+	if config.Decorations.Preset == "" {
+		config.Decorations.Preset = DefaultBoxPreset
 	}
 	if config.Decorations.Separator == "" {
 		config.Decorations.Separator = ": "
@@ -216,21 +214,67 @@ func applyConfigDefaults(config Config) Config {
 	if config.Image.Position == "" {
 		config.Image.Position = "side"
 	}
+	if config.Image.Location == "" {
+		config.Image.Location = "top"
+	}
+
+	if config.Display.Gap <= 0 {
+		config.Display.Gap = DefaultGap
+	}
+	if config.Display.Groups == "" {
+		config.Display.Groups = GroupsStack
+	}
+
+	if config.Timeouts.Command <= 0 {
+		config.Timeouts.Command = DefaultCommandTimeoutMs
+	}
+	if config.Timeouts.Fetch <= 0 {
+		config.Timeouts.Fetch = DefaultFetchTimeoutMs
+	}
+
+	config = applyModuleDefaults(config)
+
+	return config
+}
+
+func applyModuleDefaults(config Config) Config {
+	modulesConfigured := config.Modules != nil
+
+	if config.Icons == nil {
+		config.Icons = make(map[string]string)
+	}
+	if config.Modules == nil {
+		config.Modules = make(map[string]bool)
+	}
+
+	for _, reg := range components.Registered() {
+		if config.Icons[reg.Key] == "" {
+			config.Icons[reg.Key] = reg.Icon
+		}
+		if !modulesConfigured {
+			config.Modules[moduleSwitch(reg.Key)] = reg.Enabled
+		}
+	}
 
 	return config
 }
 
+func moduleSwitch(key string) string {
+	return "show_" + key
+}
+
+func (c Config) ModuleEnabled(key string) bool {
+	return c.Modules[moduleSwitch(key)]
+}
+
+func (c Config) ModuleIcon(key string) string {
+	return c.Icons[key]
+}
+
 func DefaultConfig() Config {
 	var config Config
 
-	config.Decorations.TopLeft = "╭"
-	config.Decorations.TopRight = "╮"
-	config.Decorations.BottomLeft = "╰"
-	config.Decorations.BottomRight = "╯"
-	config.Decorations.TopEdge = "─"
-	config.Decorations.BottomEdge = "─"
-	config.Decorations.LeftEdge = "│"
-	config.Decorations.RightEdge = "│"
+	config.Decorations.Preset = DefaultBoxPreset
 	config.Decorations.Separator = ": "
 
 	config.Logo.EnableLogo = true
@@ -255,47 +299,17 @@ func DefaultConfig() Config {
 	config.Image.Offset = 2
 	config.Image.Background = "transparent"
 	config.Image.Position = "side"
+	config.Image.Location = "top"
+
+	config.Timeouts.Command = DefaultCommandTimeoutMs
+	config.Timeouts.Fetch = DefaultFetchTimeoutMs
 
 	config.Display.ShowLogoFirst = true
 	config.Display.ShowImageFirst = false
+	config.Display.Gap = DefaultGap
+	config.Display.Groups = GroupsStack
 
-	config.Icons.Host = "󰒋"
-	config.Icons.User = "󰀄"
-	config.Icons.OS = "󰣇"
-	config.Icons.Kernel = "󰣇"
-	config.Icons.Uptime = "󰔟"
-	config.Icons.Terminal = "󰆍"
-	config.Icons.Shell = "󰆍"
-	config.Icons.Disk = "󰋊"
-	config.Icons.Memory = "󰍛"
-	config.Icons.Packages = "󰏗"
-	config.Icons.Battery = "󰂄"
-	config.Icons.GPU = "󰢮"
-	config.Icons.CPU = "󰘚"
-	config.Icons.Resolution = "󰍹"
-	config.Icons.DE = "󰧨"
-	config.Icons.WMTheme = "󰏘"
-	config.Icons.Theme = "󰔯"
-	config.Icons.Icons = "󰀻"
-
-	config.Modules.ShowUser = true
-	config.Modules.ShowCPU = true
-	config.Modules.ShowGPU = true
-	config.Modules.ShowUptime = true
-	config.Modules.ShowShell = true
-	config.Modules.ShowMemory = true
-	config.Modules.ShowPackages = true
-	config.Modules.ShowOS = true
-	config.Modules.ShowHost = true
-	config.Modules.ShowKernel = true
-	config.Modules.ShowBattery = true
-	config.Modules.ShowDisk = true
-	config.Modules.ShowResolution = true
-	config.Modules.ShowDE = true
-	config.Modules.ShowWMTheme = true
-	config.Modules.ShowTheme = true
-	config.Modules.ShowIcons = true
-	config.Modules.ShowTerminal = true
+	config = applyModuleDefaults(config)
 
 	return config
 }
@@ -1,224 +1,366 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
+	"lunarfetch/src/common"
 	"lunarfetch/src/components"
 )
 
+const (
+	LayoutBreak     = "break"
+	LayoutSeparator = "separator"
+	LayoutTitle     = "title"
+)
+
 type DisplayManager struct {
 	Config        Config
 	InfoProviders map[string]components.InfoProvider
-	infoCache     map[string]string
-	cacheMutex    sync.RWMutex
+	// FS and Executor replace the host filesystem and command executor for
+	// every component when set, e.g. to render fixtures
+	FS       fs.FS
+	Executor common.Executor
+	// Timings records per-module times and cache use for --timings
+	Timings    *Timings
+	infoCache  map[string]components.Result
+	infoTimes  map[string]time.Duration
+	cacheMutex sync.RWMutex
+	diskCache  *common.DiskCache
+	palette    *Palette
+	// width, hidden and stacked are set while Fit squeezes the info panel
+	// into the terminal
+	width   int
+	hidden  map[string]bool
+	stacked bool
 }
 
 func NewDisplayManager(config Config) *DisplayManager {
-	return &DisplayManager{
+	d := &DisplayManager{
 		Config:        config,
 		InfoProviders: make(map[string]components.InfoProvider),
-		infoCache:     make(map[string]string),
+		infoCache:     make(map[string]components.Result),
+		infoTimes:     make(map[string]time.Duration),
+		diskCache:     newModuleCache(config),
 	}
+	d.SetColors(config.Colors)
+	return d
 }
 
-func (d *DisplayManager) InitializeComponents() {
-	osInfo := &components.OSInfo{SystemInfo: components.SystemInfo{Name: "OS"}}
-	hostInfo := &components.HostInfo{SystemInfo: components.SystemInfo{Name: "Host"}}
-	kernelInfo := &components.KernelInfo{SystemInfo: components.SystemInfo{Name: "Kernel"}}
-	uptimeInfo := &components.UptimeInfo{SystemInfo: components.SystemInfo{Name: "Uptime"}}
-	shellInfo := &components.ShellInfo{SystemInfo: components.SystemInfo{Name: "Shell"}}
-	memoryInfo := &components.MemoryInfo{SystemInfo: components.SystemInfo{Name: "Memory"}}
-	packagesInfo := &components.PackagesInfo{SystemInfo: components.SystemInfo{Name: "Packages"}}
-	userInfo := &components.UserInfo{SystemInfo: components.SystemInfo{Name: "User"}}
-	diskInfo := &components.DiskInfo{SystemInfo: components.SystemInfo{Name: "Disk"}}
-	batteryInfo := &components.BatteryInfo{SystemInfo: components.SystemInfo{Name: "Battery"}}
-	cpuInfo := &components.CPUInfo{SystemInfo: components.SystemInfo{Name: "CPU"}}
-	gpuInfo := &components.GPUInfo{SystemInfo: components.SystemInfo{Name: "GPU"}}
-	resolutionInfo := &components.ResolutionInfo{SystemInfo: components.SystemInfo{Name: "Resolution"}}
-	deInfo := &components.DEInfo{SystemInfo: components.SystemInfo{Name: "Desktop"}}
-	terminalInfo := &components.TerminalInfo{SystemInfo: components.SystemInfo{Name: "Terminal"}}
-	themeInfo := &components.ThemeInfo{SystemInfo: components.SystemInfo{Name: "Theme"}}
-	wmThemeInfo := &components.WMThemeInfo{SystemInfo: components.SystemInfo{Name: "WM Theme"}}
-	iconsInfo := &components.IconsInfo{SystemInfo: components.SystemInfo{Name: "Icons"}}
-
-	d.InfoProviders["OS"] = osInfo
-	d.InfoProviders["Host"] = hostInfo
-	d.InfoProviders["Kernel"] = kernelInfo
-	d.InfoProviders["Uptime"] = uptimeInfo
-	d.InfoProviders["Shell"] = shellInfo
-	d.InfoProviders["Memory"] = memoryInfo
-	d.InfoProviders["Packages"] = packagesInfo
-	d.InfoProviders["User"] = userInfo
-	d.InfoProviders["Disk"] = diskInfo
-	d.InfoProviders["Battery"] = batteryInfo
-	d.InfoProviders["CPU"] = cpuInfo
-	d.InfoProviders["GPU"] = gpuInfo
-	d.InfoProviders["Resolution"] = resolutionInfo
-	d.InfoProviders["Desktop"] = deInfo
-	d.InfoProviders["Terminal"] = terminalInfo
-	d.InfoProviders["Theme"] = themeInfo
-	d.InfoProviders["WM Theme"] = wmThemeInfo
-	d.InfoProviders["Icons"] = iconsInfo
+// SetColors replaces the colors used by Display, e.g. to preview a theme
+func (d *DisplayManager) SetColors(colors ColorConfig) {
+	palette, errs := NewPalette(colors)
+	if os.Getenv("LUNARFETCH_DEBUG") == "1" {
+		for _, err := range errs {
+			fmt.Printf("Error in colors: %v\n", err)
+		}
+	}
+	d.Config.Colors = colors
+	d.palette = palette
 }
 
-func (d *DisplayManager) GetInfoParallel() {
-	var components []string
+func (d *DisplayManager) InitializeComponents() {
+	for _, reg := range components.Registered() {
+		provider := reg.New()
 
-	if d.Config.Modules.ShowHost {
-		components = append(components, "Host")
-	}
-	if d.Config.Modules.ShowUser {
-		components = append(components, "User")
-	}
-	if d.Config.Modules.ShowOS {
-		components = append(components, "OS")
-	}
-	if d.Config.Modules.ShowKernel {
-		components = append(components, "Kernel")
-	}
-	if d.Config.Modules.ShowUptime {
-		components = append(components, "Uptime")
-	}
-	if d.Config.Modules.ShowTerminal {
-		components = append(components, "Terminal")
-	}
-	if d.Config.Modules.ShowShell {
-		components = append(components, "Shell")
-	}
-	if d.Config.Modules.ShowDisk {
-		components = append(components, "Disk")
-	}
-	if d.Config.Modules.ShowMemory {
-		components = append(components, "Memory")
-	}
-	if d.Config.Modules.ShowPackages {
-		components = append(components, "Packages")
-	}
-	if d.Config.Modules.ShowBattery {
-		components = append(components, "Battery")
-	}
-	if d.Config.Modules.ShowGPU {
-		components = append(components, "GPU")
-	}
-	if d.Config.Modules.ShowCPU {
-		components = append(components, "CPU")
-	}
-	if d.Config.Modules.ShowResolution {
-		components = append(components, "Resolution")
+		if binder, ok := provider.(components.SystemBinder); ok {
+			executor := d.Executor
+			if executor == nil && d.Timings != nil {
+				// Attribute the commands each module runs in the report
+				executor = common.DefaultExecutor.For(reg.Key)
+			}
+			binder.UseSystem(d.FS, executor)
+		}
+
+		if user, ok := provider.(components.CacheUser); ok && d.diskCache != nil {
+			user.UseCache(d.diskCache)
+		}
+
+		if configurable, ok := provider.(components.Configurable); ok {
+			if options, found := d.Config.Options[reg.Key]; found {
+				if err := configurable.Configure(options); err != nil && os.Getenv("LUNARFETCH_DEBUG") == "1" {
+					fmt.Printf("Error configuring %s: %v\n", reg.Key, err)
+				}
+			}
+		}
+
+		d.InfoProviders[reg.Key] = provider
 	}
-	if d.Config.Modules.ShowWMTheme {
-		components = append(components, "WM Theme")
+}
+
+// Layout returns the entries of the info panel, those of every group in
+// turn when groups are configured
+func (d *DisplayManager) Layout() []string {
+	if len(d.Config.Groups) > 0 {
+		var layout []string
+		for _, group := range d.Config.Groups {
+			layout = append(layout, group.Layout...)
+		}
+		return layout
+	}
+	if len(d.Config.Layout) > 0 {
+		return d.Config.Layout
 	}
-	if d.Config.Modules.ShowTheme {
-		components = append(components, "Theme")
+
+	var layout []string
+	for _, reg := range components.Registered() {
+		if d.Config.ModuleEnabled(reg.Key) {
+			layout = append(layout, reg.Key)
+		}
 	}
-	if d.Config.Modules.ShowIcons {
-		components = append(components, "Icons")
+	return layout
+}
+
+func (d *DisplayManager) enabledModules() []string {
+	var modules []string
+	seen := make(map[string]bool)
+
+	add := func(key string) {
+		if seen[key] {
+			return
+		}
+		if _, ok := d.InfoProviders[key]; !ok {
+			return
+		}
+		seen[key] = true
+		modules = append(modules, key)
 	}
-	if d.Config.Modules.ShowDE {
-		components = append(components, "Desktop")
+
+	for _, entry := range d.Layout() {
+		if entry == LayoutTitle {
+			add("user")
+			add("host")
+			continue
+		}
+		add(entry)
+	}
+	return modules
+}
+
+func (d *DisplayManager) GetInfoParallel() {
+	modules := d.enabledModules()
+
+	deadline := time.Duration(d.Config.Timeouts.Fetch) * time.Millisecond
+	if deadline <= 0 {
+		deadline = DefaultFetchTimeoutMs * time.Millisecond
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+
+	common.DefaultExecutor.SetTimeout(time.Duration(d.Config.Timeouts.Command) * time.Millisecond)
+	common.DefaultExecutor.SetContext(ctx)
+	defer common.DefaultExecutor.SetContext(context.Background())
+
+	// Providers that miss the deadline keep running in the background; their
+	// late results go to this map and are discarded
+	var mutex sync.Mutex
+	results := make(map[string]components.Result)
+	times := make(map[string]time.Duration)
 
 	var wg sync.WaitGroup
-	wg.Add(len(components))
+	wg.Add(len(modules))
 
-	for _, component := range components {
-		go func(comp string) {
+	for _, module := range modules {
+		go func(key string) {
 			defer wg.Done()
-			info := d.InfoProviders[comp].GetInfo()
-			d.cacheMutex.Lock()
-			d.infoCache[comp] = info
-			d.cacheMutex.Unlock()
-		}(component)
+			start := time.Now()
+			info := d.collectInfo(key)
+			elapsed := time.Since(start)
+			mutex.Lock()
+			results[key] = info
+			times[key] = elapsed
+			mutex.Unlock()
+		}(module)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
 	}
 
-	wg.Wait()
+	mutex.Lock()
+	defer mutex.Unlock()
+	d.cacheMutex.Lock()
+	defer d.cacheMutex.Unlock()
+
+	for _, key := range modules {
+		info, ok := results[key]
+		if ok {
+			d.infoCache[key] = info
+			d.infoTimes[key] = times[key]
+		} else {
+			d.infoCache[key] = components.TimeoutResult()
+			d.infoTimes[key] = deadline
+		}
+		d.Timings.RecordModule(key, d.infoTimes[key], !ok)
+	}
 }
 
 func (d *DisplayManager) GenerateContent() string {
 	d.GetInfoParallel()
+	return d.renderContent()
+}
 
-	var content strings.Builder
+// renderContent lays out the results collected by the last GetInfoParallel
+func (d *DisplayManager) renderContent() string {
+	return d.renderEntries(d.Layout(), d.Config.Decorations, d.innerWidth(d.Config.Decorations))
+}
+
+// renderEntries lays out the given layout entries, cutting lines short at
+// width cells when width is positive
+func (d *DisplayManager) renderEntries(layout []string, decorations Decorations, width int) string {
+	var lines []string
+	var separators []int
 
 	d.cacheMutex.RLock()
 	defer d.cacheMutex.RUnlock()
 
-	if d.Config.Modules.ShowHost {
-		content.WriteString(fmt.Sprintf(" %s Host: %s\n", d.Config.Icons.Host, d.infoCache["Host"]))
-	}
-	if d.Config.Modules.ShowUser {
-		content.WriteString(fmt.Sprintf(" %s User: %s\n", d.Config.Icons.User, d.infoCache["User"]))
-	}
-	if d.Config.Modules.ShowOS {
-		content.WriteString(fmt.Sprintf(" %s OS: %s\n", d.Config.Icons.OS, d.infoCache["OS"]))
-	}
-	if d.Config.Modules.ShowKernel {
-		content.WriteString(fmt.Sprintf(" %s Kernel: %s\n", d.Config.Icons.Kernel, d.infoCache["Kernel"]))
+	for _, entry := range layout {
+		switch {
+		case entry == LayoutBreak:
+			lines = append(lines, "")
+		case entry == LayoutSeparator:
+			// Drawn below, once the width of the other lines is known
+			separators = append(separators, len(lines))
+			lines = append(lines, "")
+		case entry == LayoutTitle:
+			label := d.palette.Paint(LayoutTitle, ColorLabel)
+			at := d.palette.Paint(LayoutTitle, ColorSeparator)
+			lines = append(lines, fmt.Sprintf(" %s%s%s", label.Apply(d.infoCache["user"].String()), at.Apply("@"), label.Apply(d.infoCache["host"].String())))
+		case strings.HasPrefix(entry, LayoutTitle+":"):
+			label := d.palette.Paint(LayoutTitle, ColorLabel)
+			lines = append(lines, fmt.Sprintf(" %s", label.Apply(strings.TrimPrefix(entry, LayoutTitle+":"))))
+		default:
+			reg, ok := components.Lookup(entry)
+			if !ok {
+				if os.Getenv("LUNARFETCH_DEBUG") == "1" {
+					fmt.Printf("Unknown layout entry: %s\n", entry)
+				}
+				continue
+			}
+			if d.hidden[reg.Key] {
+				continue
+			}
+			lines = append(lines, d.moduleLines(reg)...)
+		}
 	}
-	if d.Config.Modules.ShowUptime {
-		content.WriteString(fmt.Sprintf(" %s Uptime: %s\n", d.Config.Icons.Uptime, d.infoCache["Uptime"]))
-	}
-	if d.Config.Modules.ShowTerminal {
-		content.WriteString(fmt.Sprintf(" %s Terminal: %s\n", d.Config.Icons.Terminal, d.infoCache["Terminal"]))
-	}
-	if d.Config.Modules.ShowShell {
-		content.WriteString(fmt.Sprintf(" %s Shell: %s\n", d.Config.Icons.Shell, d.infoCache["Shell"]))
-	}
-	if d.Config.Modules.ShowDisk {
-		content.WriteString(fmt.Sprintf(" %s Disk: %s\n", d.Config.Icons.Disk, d.infoCache["Disk"]))
-	}
-	if d.Config.Modules.ShowMemory {
-		content.WriteString(fmt.Sprintf(" %s Memory: %s\n", d.Config.Icons.Memory, d.infoCache["Memory"]))
-	}
-	if d.Config.Modules.ShowPackages {
-		content.WriteString(fmt.Sprintf(" %s Packages: %s\n", d.Config.Icons.Packages, d.infoCache["Packages"]))
-	}
-	if d.Config.Modules.ShowBattery {
-		content.WriteString(fmt.Sprintf(" %s Battery: %s\n", d.Config.Icons.Battery, d.infoCache["Battery"]))
-	}
-	if d.Config.Modules.ShowGPU {
-		content.WriteString(fmt.Sprintf(" %s GPU: %s\n", d.Config.Icons.GPU, d.infoCache["GPU"]))
-	}
-	if d.Config.Modules.ShowCPU {
-		content.WriteString(fmt.Sprintf(" %s CPU: %s\n", d.Config.Icons.CPU, d.infoCache["CPU"]))
+
+	if width > 0 {
+		for i, line := range lines {
+			lines[i] = Truncate(line, width, "…")
+		}
 	}
-	if d.Config.Modules.ShowResolution {
-		content.WriteString(fmt.Sprintf(" %s Resolution: %s\n", d.Config.Icons.Resolution, d.infoCache["Resolution"]))
+
+	// Separators span the lines around them, starting one cell in like them
+	separatorWidth := max(MaxWidth(lines)-1, 0)
+	separator := d.palette.Paint("", ColorSeparator)
+	for _, i := range separators {
+		line := Truncate(strings.Repeat(decorations.Separator, separatorWidth), separatorWidth, "")
+		if line != "" {
+			lines[i] = " " + separator.Apply(line)
+		}
 	}
-	if d.Config.Modules.ShowWMTheme {
-		content.WriteString(fmt.Sprintf(" %s WM Theme: %s\n", d.Config.Icons.WMTheme, d.infoCache["WM Theme"]))
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func (d *DisplayManager) moduleLines(reg components.Registration) []string {
+	result := d.infoCache[reg.Key]
+	icon := d.palette.Paint(reg.Key, ColorIcon).Apply(d.Config.ModuleIcon(reg.Key))
+	label := d.palette.Paint(reg.Key, ColorLabel)
+	separator := d.palette.Paint(reg.Key, ColorSeparator).Apply(":")
+	value := d.palette.Paint(reg.Key, ColorValue)
+
+	var lines []string
+	if (result.Value != "" && !result.Summary) || len(result.Lines) == 0 {
+		lines = append(lines, fmt.Sprintf(" %s %s%s %s", icon, label.Apply(reg.Label), separator, value.Apply(result.String())))
 	}
-	if d.Config.Modules.ShowTheme {
-		content.WriteString(fmt.Sprintf(" %s Theme: %s\n", d.Config.Icons.Theme, d.infoCache["Theme"]))
+	for _, line := range result.Lines {
+		lines = append(lines, fmt.Sprintf(" %s %s%s %s", icon, label.Apply(line.Label), separator, value.Apply(line.Value)))
 	}
-	if d.Config.Modules.ShowIcons {
-		content.WriteString(fmt.Sprintf(" %s Icons: %s\n", d.Config.Icons.Icons, d.infoCache["Icons"]))
+	return lines
+}
+
+func (d *DisplayManager) Display() string {
+	d.GetInfoParallel()
+	return d.Redisplay()
+}
+
+// Redisplay draws the info panel again from the results already collected,
+// without running the modules a second time
+func (d *DisplayManager) Redisplay() string {
+	if len(d.Config.Groups) > 0 {
+		return d.renderGroups()
 	}
-	if d.Config.Modules.ShowDE {
-		content.WriteString(fmt.Sprintf(" %s Desktop: %s\n", d.Config.Icons.DE, d.infoCache["Desktop"]))
+	return d.drawBox(d.renderContent(), d.boxConfig(d.Config.Decorations))
+}
+
+// innerWidth is the room left inside a box drawn with decorations while Fit
+// limits the width, or zero
+func (d *DisplayManager) innerWidth(decorations Decorations) int {
+	if d.width <= 0 {
+		return 0
+	}
+	margin := NewSpacing(decorations.Margin...)
+	return max(d.width-d.boxConfig(decorations).Overhead()-margin.Left-margin.Right, 1)
+}
+
+// boxConfig returns the box decorations describe: their preset with the
+// characters they set themselves
+func (d *DisplayManager) boxConfig(decorations Decorations) BoxConfig {
+	box, ok := BoxPreset(decorations.Preset)
+	if !ok {
+		if decorations.Preset != "" && os.Getenv("LUNARFETCH_DEBUG") == "1" {
+			fmt.Printf("Unknown box preset: %s\n", decorations.Preset)
+		}
+		box, _ = BoxPreset(DefaultBoxPreset)
 	}
 
-	content.WriteString(strings.Repeat(d.Config.Decorations.Separator, 30) + "\n")
+	for _, glyph := range []struct {
+		target *string
+		value  string
+	}{
+		{&box.TopLeft, decorations.TopLeft},
+		{&box.TopRight, decorations.TopRight},
+		{&box.BottomLeft, decorations.BottomLeft},
+		{&box.BottomRight, decorations.BottomRight},
+		{&box.TopEdge, decorations.TopEdge},
+		{&box.BottomEdge, decorations.BottomEdge},
+		{&box.LeftEdge, decorations.LeftEdge},
+		{&box.RightEdge, decorations.RightEdge},
+	} {
+		if glyph.value != "" {
+			*glyph.target = glyph.value
+		}
+	}
 
-	return strings.TrimSpace(content.String())
+	title := d.palette.Paint(LayoutTitle, ColorLabel)
+	box.Separator = decorations.Separator
+	box.Border = d.palette.Paint("", ColorBorder)
+	box.Title = title.Apply(decorations.Title)
+	box.TitleAlign = decorations.TitleAlign
+	box.Footer = title.Apply(decorations.Footer)
+	box.FooterAlign = decorations.FooterAlign
+	if decorations.Padding != nil {
+		padding := NewSpacing(decorations.Padding...)
+		box.Padding = &padding
+	}
+	return box
 }
 
-func (d *DisplayManager) Display() string {
-	boxConfig := BoxConfig{
-		TopLeft:     d.Config.Decorations.TopLeft,
-		TopRight:    d.Config.Decorations.TopRight,
-		BottomLeft:  d.Config.Decorations.BottomLeft,
-		BottomRight: d.Config.Decorations.BottomRight,
-		TopEdge:     d.Config.Decorations.TopEdge,
-		BottomEdge:  d.Config.Decorations.BottomEdge,
-		LeftEdge:    d.Config.Decorations.LeftEdge,
-		RightEdge:   d.Config.Decorations.RightEdge,
-		Separator:   d.Config.Decorations.Separator,
-	}
+func (d *DisplayManager) drawBox(content string, boxConfig BoxConfig) string {
 	boxDrawer := NewBoxDrawer(boxConfig)
 
-	content := d.GenerateContent()
 	return boxDrawer.Draw(content)
 }
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"lunarfetch/src/components"
+)
+
+// Fit lays out the info box, logo and image like NewLayout, from the results
+// collected by the last GetInfoParallel, and shrinks the output to cols by
+// rows cells. While it is too wide, the image is dropped, a logo beside the
+// box is moved above it, a logo wider than the terminal is dropped, groups
+// in columns are stacked and long lines are cut short. While it is too
+// tall, the image and logo are dropped and then modules are hidden, lowest
+// priority first. A zero size is unknown and leaves that direction alone.
+func (d *DisplayManager) Fit(logo, image string, cols, rows int) string {
+	config := d.Config
+	d.hidden = make(map[string]bool)
+	defer func() {
+		d.width = 0
+		d.hidden = nil
+		d.stacked = false
+	}()
+
+	render := func() string {
+		return NewLayout(config, d.Redisplay(), logo, image).Render()
+	}
+	output := render()
+	if config.Fit.Disabled {
+		return output
+	}
+
+	if cols > 0 {
+		if outputWidth(output) > cols && image != "" {
+			image = ""
+			output = render()
+		}
+		if outputWidth(output) > cols && logo != "" && besideBox(config.Logo.Position) {
+			config.Logo.Position = "above"
+			output = render()
+		}
+		if outputWidth(output) > cols && MaxWidth(blockLines(logo)) > cols {
+			logo = ""
+			output = render()
+		}
+		if outputWidth(output) > cols && len(config.Groups) > 0 && config.Display.Groups == GroupsColumns {
+			d.stacked = true
+			output = render()
+		}
+		if outputWidth(output) > cols {
+			d.width = cols
+			output = render()
+		}
+	}
+
+	if rows > 0 {
+		// Leave a line for the prompt
+		limit := max(rows-1, 1)
+		if outputHeight(output) > limit && image != "" {
+			if shorter := NewLayout(config, d.Redisplay(), logo, "").Render(); outputHeight(shorter) < outputHeight(output) {
+				image = ""
+				output = shorter
+			}
+		}
+		if outputHeight(output) > limit && logo != "" {
+			if shorter := NewLayout(config, d.Redisplay(), "", image).Render(); outputHeight(shorter) < outputHeight(output) {
+				logo = ""
+				output = shorter
+			}
+		}
+		for _, key := range d.hideOrder() {
+			if outputHeight(output) <= limit {
+				break
+			}
+			d.hidden[key] = true
+			output = render()
+		}
+	}
+
+	return output
+}
+
+// besideBox tells whether a logo or image position puts it next to the box
+func besideBox(position string) bool {
+	switch PanelRegion(position) {
+	case RegionLeft, RegionRight, RegionCenter:
+		return true
+	}
+	return false
+}
+
+// ModulePriority returns how important a module is to keep when the output
+// does not fit the terminal: the fit.priority setting, else the module's own
+func (d *DisplayManager) ModulePriority(key string) int {
+	if priority, ok := d.Config.Fit.Priority[key]; ok {
+		return priority
+	}
+	if reg, ok := components.Lookup(key); ok && reg.Priority != 0 {
+		return reg.Priority
+	}
+	return components.DefaultPriority
+}
+
+// hideOrder lists the modules in the layout in the order Fit hides them:
+// lowest priority first, and the lowest in the box first among equals
+func (d *DisplayManager) hideOrder() []string {
+	var modules []string
+	position := make(map[string]int)
+	for _, entry := range d.Layout() {
+		if _, seen := position[entry]; seen {
+			continue
+		}
+		if _, ok := components.Lookup(entry); ok {
+			position[entry] = len(modules)
+			modules = append(modules, entry)
+		}
+	}
+
+	sort.SliceStable(modules, func(i, j int) bool {
+		pi, pj := d.ModulePriority(modules[i]), d.ModulePriority(modules[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return position[modules[i]] > position[modules[j]]
+	})
+	return modules
+}
+
+func outputWidth(output string) int {
+	return MaxWidth(strings.Split(output, "\n"))
+}
+
+func outputHeight(output string) int {
+	if output == "" {
+		return 0
+	}
+	return strings.Count(output, "\n") + 1
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"lunarfetch/src/components"
+)
+
+// fitManager returns a display manager with results already collected
+func fitManager(t *testing.T) *DisplayManager {
+	t.Helper()
+	config := DefaultConfig()
+	config.Layout = []string{"os", "kernel", "gpu", "theme", "icons"}
+	config.Icons = map[string]string{}
+	config.Logo.Location = "top"
+
+	d := NewDisplayManager(config)
+	d.infoCache["os"] = components.Result{Value: "Arch Linux"}
+	d.infoCache["kernel"] = components.Result{Value: "6.9.1"}
+	d.infoCache["gpu"] = components.Result{Value: "NVIDIA GeForce RTX 4090 Laptop GPU"}
+	d.infoCache["theme"] = components.Result{Value: "Adwaita"}
+	d.infoCache["icons"] = components.Result{Value: "Papirus"}
+	return d
+}
+
+func TestFitLeavesOutputThatFits(t *testing.T) {
+	d := fitManager(t)
+	want := NewLayout(d.Config, d.Redisplay(), logoBlock, imageBlock).Render()
+
+	if got := d.Fit(logoBlock, imageBlock, 0, 0); got != want {
+		t.Errorf("unknown size:\n%s\nwant\n%s", got, want)
+	}
+	if got := d.Fit(logoBlock, imageBlock, 200, 50); got != want {
+		t.Errorf("large terminal:\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	d := fitManager(t)
+	box := d.Redisplay()
+	boxWidth := MaxWidth(strings.Split(box, "\n"))
+
+	// The image goes first
+	got := d.Fit(logoBlock, imageBlock, boxWidth+DefaultGap+2, 0)
+	if strings.Contains(got, "#") || !strings.Contains(got, "/\\") {
+		t.Errorf("without the image:\n%s", got)
+	}
+
+	// Then the logo moves above the box
+	got = d.Fit(logoBlock, imageBlock, boxWidth, 0)
+	if want := "/\\\n\\/\n" + strings.TrimRight(box, "\n"); got != want {
+		t.Errorf("logo above:\n%s\nwant\n%s", got, want)
+	}
+
+	// Then long values are cut short
+	got = d.Fit(logoBlock, imageBlock, 30, 0)
+	for _, line := range strings.Split(got, "\n") {
+		if width := DisplayWidth(line); width > 30 {
+			t.Errorf("line %q is %d cells wide", line, width)
+		}
+	}
+	if !strings.Contains(got, "GPU: NVIDIA GeForce RTX…") {
+		t.Errorf("gpu not ellipsized:\n%s", got)
+	}
+
+	// Fit leaves later renders alone
+	if d.Redisplay() != box {
+		t.Error("Fit changed the box drawn afterwards")
+	}
+}
+
+func TestFitHeight(t *testing.T) {
+	d := fitManager(t)
+	d.Config.Logo.Position = "above"
+
+	// The box of 5 modules is 7 lines tall, the logo 2 more
+	got := d.Fit(logoBlock, "", 0, 9)
+	if strings.Contains(got, "/\\") || strings.Count(got, "\n") != 6 {
+		t.Errorf("without the logo:\n%s", got)
+	}
+
+	// icons and theme share the lowest priority; icons is further down
+	got = d.Fit(logoBlock, "", 0, 7)
+	if strings.Contains(got, "Icons") || !strings.Contains(got, "Theme") {
+		t.Errorf("icons hidden first:\n%s", got)
+	}
+	// Then theme, and gpu before kernel
+	got = d.Fit(logoBlock, "", 0, 5)
+	if strings.Contains(got, "Theme") || strings.Contains(got, "GPU") || !strings.Contains(got, "Kernel") {
+		t.Errorf("theme then gpu hidden:\n%s", got)
+	}
+
+	// The config can change the order
+	d.Config.Fit.Priority = map[string]int{"gpu": 1}
+	got = d.Fit(logoBlock, "", 0, 7)
+	if strings.Contains(got, "GPU") || !strings.Contains(got, "Icons") {
+		t.Errorf("gpu hidden first:\n%s", got)
+	}
+
+	d.Config.Fit.Disabled = true
+	if got := d.Fit(logoBlock, "", 0, 2); !strings.Contains(got, "/\\") || !strings.Contains(got, "GPU") {
+		t.Errorf("disabled fit changed the output:\n%s", got)
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"strings"
+)
+
+// How groups are arranged in the info panel
+const (
+	GroupsStack   = "stack"
+	GroupsColumns = "columns"
+)
+
+// Group styles: a box with the title in its top edge, or a line with the
+// title in it above the group's lines
+const (
+	GroupBox     = "box"
+	GroupDivider = "divider"
+)
+
+// mergeDecorations returns the decorations of a group: base with what the
+// group sets itself. A preset of the group's own replaces the characters set
+// in base, and the title, footer and margin are never taken from base.
+func mergeDecorations(base, override Decorations) Decorations {
+	if override.Preset != "" {
+		base = Decorations{Preset: override.Preset, Separator: base.Separator, TitleAlign: base.TitleAlign, FooterAlign: base.FooterAlign, Padding: base.Padding}
+	}
+	pick := func(base, override string) string {
+		if override != "" {
+			return override
+		}
+		return base
+	}
+	padding := base.Padding
+	if override.Padding != nil {
+		padding = override.Padding
+	}
+	return Decorations{
+		Preset:      base.Preset,
+		TopLeft:     pick(base.TopLeft, override.TopLeft),
+		TopRight:    pick(base.TopRight, override.TopRight),
+		BottomLeft:  pick(base.BottomLeft, override.BottomLeft),
+		BottomRight: pick(base.BottomRight, override.BottomRight),
+		TopEdge:     pick(base.TopEdge, override.TopEdge),
+		BottomEdge:  pick(base.BottomEdge, override.BottomEdge),
+		LeftEdge:    pick(base.LeftEdge, override.LeftEdge),
+		RightEdge:   pick(base.RightEdge, override.RightEdge),
+		Separator:   pick(base.Separator, override.Separator),
+		TitleAlign:  pick(base.TitleAlign, override.TitleAlign),
+		Footer:      override.Footer,
+		FooterAlign: pick(base.FooterAlign, override.FooterAlign),
+		Padding:     padding,
+		Margin:      override.Margin,
+	}
+}
+
+// groupBlock is a group with its lines laid out, ready to be drawn
+type groupBlock struct {
+	style   string
+	box     BoxConfig
+	margin  Spacing
+	content string
+}
+
+// width is the number of cells the group takes when drawn at its own size
+func (b groupBlock) width() int {
+	return NewBoxDrawer(b.box).Width(b.content)
+}
+
+// renderGroups draws every group that has something to show, stacked or in
+// columns. Stacked groups are drawn equally wide so their edges line up.
+func (d *DisplayManager) renderGroups() string {
+	title := d.palette.Paint(LayoutTitle, ColorLabel)
+
+	var blocks []groupBlock
+	for _, group := range d.Config.Groups {
+		decorations := mergeDecorations(d.Config.Decorations, group.Decorations)
+		content := d.renderEntries(group.Layout, decorations, d.innerWidth(decorations))
+		if strings.TrimSpace(StripANSI(content)) == "" {
+			continue
+		}
+		box := d.boxConfig(decorations)
+		box.Title = title.Apply(group.Title)
+		blocks = append(blocks, groupBlock{
+			style:   group.Style,
+			box:     box,
+			margin:  NewSpacing(decorations.Margin...),
+			content: content,
+		})
+	}
+
+	stacked := d.stacked || d.Config.Display.Groups != GroupsColumns
+	width := 0
+	region := RegionLeft
+	if stacked {
+		region = RegionCenter
+		for _, block := range blocks {
+			width = max(width, block.width())
+		}
+	}
+
+	layout := Layout{Gap: d.Config.Display.Gap}
+	for _, block := range blocks {
+		layout.Add(Panel{Content: d.drawGroup(block, width), Region: region, Margin: block.margin})
+	}
+	return layout.Render() + "\n"
+}
+
+// drawGroup draws a group width cells wide, or at its own size for zero
+func (d *DisplayManager) drawGroup(block groupBlock, width int) string {
+	if width <= 0 {
+		width = block.width()
+	}
+	if block.style == GroupDivider {
+		return d.drawDivider(block, width)
+	}
+	box := block.box
+	box.MinWidth = width - box.Overhead()
+	return d.drawBox(block.content, box)
+}
+
+// drawDivider draws the group's lines under a line with its title in it,
+// indented like the lines of a box
+func (d *DisplayManager) drawDivider(block groupBlock, width int) string {
+	box := block.box
+	var lines []string
+	if divider := edgeLine("", box.TopEdge, "", box.Title, box.TitleAlign, width, box.Border); divider != "" {
+		lines = append(lines, divider)
+	}
+
+	indent := strings.Repeat(" ", DisplayWidth(box.LeftEdge)+box.padding().Left)
+	for _, line := range strings.Split(block.content, "\n") {
+		lines = append(lines, indent+line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// groupManager splits the results of fitManager into three groups
+func groupManager(t *testing.T, arrange string) *DisplayManager {
+	t.Helper()
+	d := fitManager(t)
+	d.Config.Display.Groups = arrange
+	d.Config.Groups = []Group{
+		{Title: "Hardware", Layout: []string{"gpu"}},
+		{Title: "Software", Layout: []string{"os", "separator", "kernel"}, Decorations: Decorations{
+			TopLeft: "┏", TopRight: "┓", BottomLeft: "┗", BottomRight: "┛",
+			TopEdge: "━", BottomEdge: "━", LeftEdge: "┃", RightEdge: "┃", Separator: "-",
+		}},
+		{Title: "Look", Style: GroupDivider, Layout: []string{"theme", "icons"}},
+	}
+	return d
+}
+
+func TestGroupsGolden(t *testing.T) {
+	golden(t, "groups-stack", groupManager(t, GroupsStack).Redisplay())
+	golden(t, "groups-columns", groupManager(t, GroupsColumns).Redisplay())
+}
+
+func TestGroupsLayout(t *testing.T) {
+	d := groupManager(t, GroupsStack)
+	want := []string{"gpu", "os", "separator", "kernel", "theme", "icons"}
+	if got := d.Layout(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Layout() = %v, want %v", got, want)
+	}
+}
+
+func TestGroupsFit(t *testing.T) {
+	d := groupManager(t, GroupsColumns)
+	stacked := groupManager(t, GroupsStack).Redisplay()
+	stackedWidth := MaxWidth(strings.Split(stacked, "\n"))
+
+	// Columns that don't fit are stacked before anything is cut short
+	if got := d.Fit("", "", stackedWidth, 0); got != strings.TrimRight(stacked, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", got, stacked)
+	}
+
+	// A group whose modules are all hidden is left out
+	d = groupManager(t, GroupsStack)
+	got := d.Fit("", "", 0, 10)
+	if strings.Contains(got, "Look") || strings.Count(got, "\n") != 7 {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestBoxTitle(t *testing.T) {
+	box := NewBoxDrawer(BoxConfig{
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		TopEdge: "-", BottomEdge: "-", LeftEdge: "|", RightEdge: "|",
+		Title: "Title", MinWidth: 3,
+	}).Draw("ab")
+
+	want := "+- Title +\n| ab     |\n+--------+\n"
+	if box != want {
+		t.Errorf("got\n%s\nwant\n%s", box, want)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/disintegration/imaging"
 	"github.com/mattn/go-sixel"
 	_ "golang.org/x/image/webp"
+
+	"lunarfetch/src/common"
 )
 
 const (
@@ -58,6 +60,8 @@ const (
 
 type ImageLoader struct {
 	Config ImageConfig
+	// Timings receives the decode, resize, dither and encode times
+	Timings *Timings
 }
 
 type ImageConfig struct {
@@ -204,30 +208,26 @@ func (i *ImageLoader) CalculateOptimalDimensions(img image.Image) (int, int) {
 }
 
 func getTerminalSize() (int, int) {
+	if width, height := TerminalSize(); width > 0 && height > 0 {
+		return width, height
+	}
 
-	widthCmd := exec.Command("tput", "cols")
-	widthCmd.Stdin = os.Stdin
-	widthOut, widthErr := widthCmd.Output()
-
-	heightCmd := exec.Command("tput", "lines")
-	heightCmd.Stdin = os.Stdin
-	heightOut, heightErr := heightCmd.Output()
+	widthOut, widthErr := common.GlobalCommandExecutor.ExecuteWithStdin("tput", "cols")
+	heightOut, heightErr := common.GlobalCommandExecutor.ExecuteWithStdin("tput", "lines")
 
 	if widthErr == nil && heightErr == nil {
-		width, _ := strconv.Atoi(strings.TrimSpace(string(widthOut)))
-		height, _ := strconv.Atoi(strings.TrimSpace(string(heightOut)))
+		width, _ := strconv.Atoi(widthOut)
+		height, _ := strconv.Atoi(heightOut)
 		return width, height
 	}
 
-	cmd := exec.Command("stty", "size")
-	cmd.Stdin = os.Stdin
-	out, err := cmd.Output()
+	out, err := common.GlobalCommandExecutor.ExecuteWithStdin("stty", "size")
 	if err != nil {
 
 		return 80, 24
 	}
 
-	parts := strings.Split(strings.TrimSpace(string(out)), " ")
+	parts := strings.Split(out, " ")
 	if len(parts) != 2 {
 		return 80, 24
 	}
@@ -300,15 +300,13 @@ func (i *ImageLoader) DisplayWithUberzug(img image.Image, path string) (string,
 		return "", fmt.Errorf("error saving image: %v", err)
 	}
 
-	cmd := exec.Command("stty", "size")
-	cmd.Stdin = os.Stdin
-	out, err := cmd.Output()
+	out, err := common.GlobalCommandExecutor.ExecuteWithStdin("stty", "size")
 	if err != nil {
 		return "", fmt.Errorf("error getting terminal size: %v", err)
 	}
 
 	var rows, cols int
-	fmt.Sscanf(string(out), "%d %d", &rows, &cols)
+	fmt.Sscanf(out, "%d %d", &rows, &cols)
 
 	x := i.Config.Offset
 	y := i.Config.Offset
@@ -477,11 +475,14 @@ func clamp(value int) int {
 
 func (i *ImageLoader) RenderImage() (string, error) {
 
+	start := time.Now()
 	img, err := i.LoadImage(i.Config.ImagePath)
+	i.Timings.RecordPhase("image decode", time.Since(start))
 	if err != nil {
 		return "", err
 	}
 
+	start = time.Now()
 	optWidth, optHeight := i.CalculateOptimalDimensions(img)
 
 	originalWidth := i.Config.Width
@@ -490,13 +491,18 @@ func (i *ImageLoader) RenderImage() (string, error) {
 	i.Config.Height = optHeight
 
 	img = i.ResizeImage(img)
+	i.Timings.RecordPhase("image resize", time.Since(start))
 
 	if i.Config.DitherMode != "none" {
+		start = time.Now()
 		img = i.ApplyDithering(img)
+		i.Timings.RecordPhase("image dither", time.Since(start))
 	}
 
 	var output string
 
+	start = time.Now()
+
 	switch i.Config.Protocol {
 	case "sixel":
 		output, err = i.DisplayWithSixel(img)
@@ -512,6 +518,7 @@ func (i *ImageLoader) RenderImage() (string, error) {
 
 		output, err = i.AutoDetectProtocol(img)
 	}
+	i.Timings.RecordPhase("image encode", time.Since(start))
 
 	i.Config.Width = originalWidth
 	i.Config.Height = originalHeight
@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"strings"
+)
+
+// Regions a panel can be placed in, around the center
+const (
+	RegionTop    = "top"
+	RegionBottom = "bottom"
+	RegionLeft   = "left"
+	RegionRight  = "right"
+	RegionCenter = "center"
+)
+
+// Vertical alignment of a panel next to taller ones
+const (
+	AlignTop    = "top"
+	AlignMiddle = "middle"
+	AlignBottom = "bottom"
+)
+
+// DefaultGap is the number of cells between side-by-side panels
+const DefaultGap = 2
+
+// Panel is a block of output, such as the logo, the image or an info box
+type Panel struct {
+	Content string
+	Region  string
+	Align   string
+	// Margin is blank room kept around the panel
+	Margin Spacing
+	// Width and Height are the cells kept for content the terminal draws
+	// itself, such as a sixel or kitty image, whose escape codes take no room
+	// in the text. The content is then placed as it is, blank lines and all.
+	Width, Height int
+}
+
+// opaque tells whether the panel's content is drawn by the terminal in the
+// cells it keeps rather than measured
+func (p Panel) opaque() bool {
+	return p.Width > 0 || p.Height > 0
+}
+
+// block returns the lines of the panel and the number of cells they are wide
+func (p Panel) block() ([]string, int) {
+	if !p.opaque() {
+		lines := blockLines(p.Content)
+		return lines, MaxWidth(lines)
+	}
+
+	lines := strings.Split(strings.TrimRight(p.Content, "\n"), "\n")
+	for len(lines) < p.Height {
+		lines = append(lines, "")
+	}
+	return lines, max(MaxWidth(lines), p.Width)
+}
+
+// Layout arranges panels in five regions. The left, center and right panels
+// form one row of columns; top and bottom panels are stacked above and below
+// it. Panels sharing a region keep the order they were added in, left to
+// right or top to bottom, and center panels are stacked into one column.
+type Layout struct {
+	Panels []Panel
+	Gap    int
+}
+
+// Add appends a panel; panels without content are left out. Content that is
+// only escape codes counts when the panel keeps cells for it.
+func (l *Layout) Add(panel Panel) {
+	if strings.TrimSpace(panel.Content) == "" {
+		return
+	}
+	if !panel.opaque() && strings.TrimSpace(StripANSI(panel.Content)) == "" {
+		return
+	}
+	l.Panels = append(l.Panels, panel)
+}
+
+// PanelRegion maps a logo or image position from the config to a region.
+// "side", the default, is the legacy name for "right".
+func PanelRegion(position string) string {
+	switch position {
+	case "left":
+		return RegionLeft
+	case "above", "top":
+		return RegionTop
+	case "below", "bottom":
+		return RegionBottom
+	case "center":
+		return RegionCenter
+	default:
+		return RegionRight
+	}
+}
+
+// PanelAlign maps a location from the config to a vertical alignment
+func PanelAlign(location string) string {
+	switch location {
+	case "center", "middle":
+		return AlignMiddle
+	case "bottom":
+		return AlignBottom
+	default:
+		return AlignTop
+	}
+}
+
+// NewLayout places the info box, logo and image as configured
+func NewLayout(config Config, info, logo, image string) Layout {
+	layout := Layout{Gap: config.Display.Gap}
+	layout.Add(Panel{Content: info, Region: RegionCenter, Align: AlignTop, Margin: NewSpacing(config.Decorations.Margin...)})
+
+	logoPanel := Panel{Content: logo, Region: PanelRegion(config.Logo.Position), Align: PanelAlign(config.Logo.Location)}
+	imagePanel := Panel{Content: image, Region: PanelRegion(config.Image.Position), Align: PanelAlign(config.Image.Location)}
+	if !config.Logo.EnableLogo {
+		logoPanel.Content = ""
+	}
+	if !config.Image.EnableImage {
+		imagePanel.Content = ""
+	}
+	// Sixel, kitty and iTerm2 images are escape codes the terminal draws as a
+	// picture the configured size, so that room is kept beside the box
+	if strings.TrimSpace(image) != "" && strings.TrimSpace(StripANSI(image)) == "" {
+		imagePanel.Width = config.Image.Width
+		imagePanel.Height = config.Image.Height
+	}
+
+	// Panels sharing a side are shown in display order
+	if config.Display.ShowImageFirst && !config.Display.ShowLogoFirst {
+		layout.Add(imagePanel)
+		layout.Add(logoPanel)
+	} else {
+		layout.Add(logoPanel)
+		layout.Add(imagePanel)
+	}
+	return layout
+}
+
+// blockLines splits content into lines without trailing blanks or blank
+// lines around it
+func blockLines(content string) []string {
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	for len(lines) > 0 && strings.TrimSpace(StripANSI(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(StripANSI(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// withMargin surrounds width cells wide lines with blank room
+func withMargin(lines []string, width int, margin Spacing) ([]string, int) {
+	if margin == (Spacing{}) {
+		return lines, width
+	}
+
+	width += margin.Left + margin.Right
+	indent := strings.Repeat(" ", margin.Left)
+	spaced := make([]string, 0, margin.Top+len(lines)+margin.Bottom)
+	for range margin.Top {
+		spaced = append(spaced, "")
+	}
+	for _, line := range lines {
+		// Padded so the right margin holds in a row of columns
+		spaced = append(spaced, PadRight(indent+line, width))
+	}
+	for range margin.Bottom {
+		spaced = append(spaced, "")
+	}
+	return spaced, width
+}
+
+type column struct {
+	lines []string
+	width int
+	align string
+}
+
+// alignedLine returns the line of c shown in row of a height rows tall row
+func (c column) alignedLine(row, height int) string {
+	offset := 0
+	switch c.align {
+	case AlignMiddle:
+		offset = (height - len(c.lines)) / 2
+	case AlignBottom:
+		offset = height - len(c.lines)
+	}
+	if index := row - offset; index >= 0 && index < len(c.lines) {
+		return c.lines[index]
+	}
+	return ""
+}
+
+// Render draws the panels
+func (l Layout) Render() string {
+	gap := l.Gap
+	if gap <= 0 {
+		gap = DefaultGap
+	}
+
+	var top, bottom, center []string
+	var left, right []column
+	centerAlign := AlignTop
+	centerWidth := 0
+	for _, panel := range l.Panels {
+		lines, width := panel.block()
+		if len(lines) == 0 {
+			continue
+		}
+		lines, width = withMargin(lines, width, panel.Margin)
+
+		switch panel.Region {
+		case RegionTop:
+			top = append(top, lines...)
+		case RegionBottom:
+			bottom = append(bottom, lines...)
+		case RegionLeft:
+			left = append(left, column{lines: lines, width: width, align: panel.Align})
+		case RegionRight:
+			right = append(right, column{lines: lines, width: width, align: panel.Align})
+		default:
+			if len(center) == 0 {
+				centerAlign = panel.Align
+			}
+			center = append(center, lines...)
+			centerWidth = max(centerWidth, width)
+		}
+	}
+
+	columns := left
+	if len(center) > 0 {
+		columns = append(columns, column{lines: center, width: centerWidth, align: centerAlign})
+	}
+	columns = append(columns, right...)
+
+	var output []string
+	output = append(output, top...)
+	output = append(output, renderRow(columns, gap)...)
+	output = append(output, bottom...)
+	for i, line := range output {
+		// Margins of panels above and below leave trailing spaces
+		output[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(output, "\n")
+}
+
+// renderRow puts the columns next to each other, gap cells apart
+func renderRow(columns []column, gap int) []string {
+	height := 0
+	for _, column := range columns {
+		height = max(height, len(column.lines))
+	}
+
+	spacing := strings.Repeat(" ", gap)
+	rows := make([]string, height)
+	for row := range rows {
+		var line strings.Builder
+		for i, column := range columns {
+			if i > 0 {
+				line.WriteString(spacing)
+			}
+			line.WriteString(PadRight(column.alignedLine(row, height), column.width))
+		}
+		rows[row] = strings.TrimRight(line.String(), " ")
+	}
+	return rows
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	infoBlock  = "[info 1]\n[info 2]\n[info 3]\n[info 4]"
+	logoBlock  = "/\\\n\\/"
+	imageBlock = "####\n####\n####"
+)
+
+func layoutConfig(logoPosition, imagePosition string) Config {
+	config := DefaultConfig()
+	config.Logo.Position = logoPosition
+	config.Logo.Location = "top"
+	config.Image.Position = imagePosition
+	return config
+}
+
+func TestLayoutDefaultPositionShowsLogo(t *testing.T) {
+	config := DefaultConfig()
+	if config.Logo.Position != "side" {
+		t.Fatalf("default logo position = %q", config.Logo.Position)
+	}
+	config.Image.EnableImage = false
+
+	got := NewLayout(config, infoBlock, logoBlock, "").Render()
+	want := "[info 1]\n[info 2]  /\\\n[info 3]  \\/\n[info 4]"
+	if got != want {
+		t.Errorf("got\n%s\nwant the logo right of the info, centered\n%s", got, want)
+	}
+}
+
+func TestLayoutPositions(t *testing.T) {
+	tests := []struct {
+		logo, image string
+		want        string
+	}{
+		{"left", "right", "/\\  [info 1]  ####\n\\/  [info 2]  ####\n    [info 3]  ####\n    [info 4]"},
+		{"right", "left", "####  [info 1]  /\\\n####  [info 2]  \\/\n####  [info 3]\n      [info 4]"},
+		{"above", "below", "/\\\n\\/\n[info 1]\n[info 2]\n[info 3]\n[info 4]\n####\n####\n####"},
+		{"above", "right", "/\\\n\\/\n[info 1]  ####\n[info 2]  ####\n[info 3]  ####\n[info 4]"},
+		// Both on one side, in display order: the logo first
+		{"left", "left", "/\\  ####  [info 1]\n\\/  ####  [info 2]\n    ####  [info 3]\n          [info 4]"},
+		{"below", "below", "[info 1]\n[info 2]\n[info 3]\n[info 4]\n/\\\n\\/\n####\n####\n####"},
+	}
+
+	for _, test := range tests {
+		config := layoutConfig(test.logo, test.image)
+		if got := NewLayout(config, infoBlock, logoBlock, imageBlock).Render(); got != test.want {
+			t.Errorf("logo %s, image %s:\n%s\nwant\n%s", test.logo, test.image, got, test.want)
+		}
+	}
+}
+
+func TestLayoutShowImageFirst(t *testing.T) {
+	config := layoutConfig("right", "right")
+	config.Display.ShowLogoFirst = false
+	config.Display.ShowImageFirst = true
+
+	got := NewLayout(config, infoBlock, logoBlock, imageBlock).Render()
+	if first := strings.Split(got, "\n")[0]; first != "[info 1]  ####  /\\" {
+		t.Errorf("first line = %q", first)
+	}
+}
+
+func TestLayoutDisabledAndEmptyPanels(t *testing.T) {
+	config := layoutConfig("left", "left")
+	config.Logo.EnableLogo = false
+
+	// The image failed to load, and the logo is turned off
+	if got := NewLayout(config, infoBlock, logoBlock, "\n  \n").Render(); got != infoBlock {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestLayoutAlignAndGap(t *testing.T) {
+	layout := Layout{Gap: 1}
+	layout.Add(Panel{Content: "a\nb\nc\nd\ne", Region: RegionLeft})
+	layout.Add(Panel{Content: "\033[31mx\033[0m", Region: RegionCenter, Align: AlignBottom})
+	layout.Add(Panel{Content: "日\n本", Region: RegionRight, Align: AlignMiddle})
+	layout.Add(Panel{Content: "tail", Region: RegionRight})
+
+	want := "a      tail\nb   日\nc   本\nd\ne \033[31mx\033[0m"
+	if got := layout.Render(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLayoutKeepsBlankLinesInsideBlocks(t *testing.T) {
+	layout := Layout{}
+	layout.Add(Panel{Content: "\n  top\n\n  bottom  \n\n", Region: RegionLeft})
+	layout.Add(Panel{Content: "1\n2\n3", Region: RegionCenter})
+
+	want := "  top     1\n          2\n  bottom  3"
+	if got := layout.Render(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLayoutMargin(t *testing.T) {
+	layout := Layout{Gap: 1}
+	layout.Add(Panel{Content: "ab\ncd", Region: RegionLeft, Margin: NewSpacing(1, 2, 0, 1)})
+	layout.Add(Panel{Content: "x", Region: RegionCenter})
+	layout.Add(Panel{Content: "top", Region: RegionTop, Margin: Spacing{Left: 4, Right: 4}})
+
+	want := "    top\n      x\n ab\n cd"
+	if got := layout.Render(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLayoutKeepsRoomForTerminalImages(t *testing.T) {
+	tests := map[string]string{
+		"sixel": "\x1bPq#0;2;0;0;0#0~~~~~~-~~~~~~\x1b\\",
+		"kitty": "\x1b_Ga=T,f=100,s=6,v=3;iVBORw0KGgo=\x1b\\\n",
+	}
+	for name, image := range tests {
+		config := layoutConfig("left", "left")
+		config.Logo.EnableLogo = false
+		config.Image.Width = 6
+		config.Image.Height = 5
+
+		// The escape codes are kept as they are, the box starts past the cells
+		// the picture is drawn in and output below it starts past its height
+		got := NewLayout(config, infoBlock, logoBlock, image).Render()
+		want := strings.Join([]string{
+			strings.TrimRight(image, "\n") + "        [info 1]",
+			"        [info 2]",
+			"        [info 3]",
+			"        [info 4]",
+			"",
+		}, "\n")
+		if got != want {
+			t.Errorf("%s: got\n%q\nwant\n%q", name, got, want)
+		}
+	}
+}
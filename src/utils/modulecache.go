@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"encoding/json"
+	"time"
+
+	"lunarfetch/src/common"
+	"lunarfetch/src/components"
+)
+
+type cachedResult struct {
+	Value string `json:"value"`
+	// Fields is decoded like toGeneric does, so a cached result gives the
+	// same output as a fresh one
+	Fields json.RawMessage   `json:"fields"`
+	Lines  []components.Line `json:"lines,omitempty"`
+	// Summary is kept so a cached summary is not drawn above its lines
+	Summary bool `json:"summary,omitempty"`
+}
+
+func moduleCacheName(key string) string {
+	return "module:" + key
+}
+
+func (d *DisplayManager) moduleCacheTTL(key string) time.Duration {
+	if seconds, ok := d.Config.Cache.TTL[key]; ok {
+		return time.Duration(seconds) * time.Second
+	}
+	if reg, ok := components.Lookup(key); ok {
+		return reg.CacheTTL
+	}
+	return 0
+}
+
+// The cache key combines the module's own invalidation key with its options,
+// so editing config.json never serves a result rendered with old settings
+func (d *DisplayManager) moduleCacheKey(key string, provider components.InfoProvider) (string, bool) {
+	cacheable, ok := provider.(components.Cacheable)
+	if !ok {
+		return "", false
+	}
+	invalidation, ok := cacheable.CacheKey()
+	if !ok {
+		return "", false
+	}
+	return string(d.Config.Options[key]) + "|" + invalidation, true
+}
+
+func (d *DisplayManager) collectInfo(key string) components.Result {
+	provider := d.InfoProviders[key]
+
+	if d.diskCache == nil {
+		return provider.GetInfo()
+	}
+	ttl := d.moduleCacheTTL(key)
+	if ttl <= 0 {
+		return provider.GetInfo()
+	}
+	cacheKey, ok := d.moduleCacheKey(key, provider)
+	if !ok {
+		return provider.GetInfo()
+	}
+
+	var cached cachedResult
+	hit := d.diskCache.Get(moduleCacheName(key), cacheKey, &cached)
+	d.Timings.RecordCache(key, hit)
+	if hit {
+		fields := components.Fields{}
+		if generic, err := decodeGeneric(cached.Fields); err == nil {
+			if m, ok := generic.(map[string]interface{}); ok {
+				fields = m
+			}
+		}
+		if refreshable, ok := provider.(components.Refreshable); ok {
+			for name, value := range refreshable.LiveFields() {
+				fields[name] = value
+			}
+		}
+		result := components.NewResult(cached.Value, fields)
+		result.Lines = cached.Lines
+		result.Summary = cached.Summary
+		return result
+	}
+
+	result := provider.GetInfo()
+	if result.Err == nil {
+		stored := result.Fields
+		if refreshable, ok := provider.(components.Refreshable); ok {
+			stored = make(components.Fields, len(result.Fields))
+			for name, value := range result.Fields {
+				stored[name] = value
+			}
+			for name := range refreshable.LiveFields() {
+				delete(stored, name)
+			}
+		}
+		fields, err := json.Marshal(stored)
+		if err == nil {
+			d.diskCache.Set(moduleCacheName(key), cacheKey, ttl, cachedResult{
+				Value:   result.Value,
+				Fields:  fields,
+				Lines:   result.Lines,
+				Summary: result.Summary,
+			})
+		}
+	}
+	return result
+}
+
+func newModuleCache(config Config) *common.DiskCache {
+	if !config.Cache.Enabled {
+		return nil
+	}
+	return common.NewDiskCache(common.DefaultCacheDir())
+}
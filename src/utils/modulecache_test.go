@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"lunarfetch/src/common"
+	"lunarfetch/src/components"
+)
+
+// sensorInfo is a cacheable module with typed fields and a live reading
+type sensorInfo struct {
+	calls   int
+	reading float64
+}
+
+type sensorChip struct {
+	Name  string `json:"name"`
+	Cores int    `json:"cores"`
+}
+
+func (s *sensorInfo) GetName() string { return "Sensor" }
+
+func (s *sensorInfo) GetInfo() components.Result {
+	s.calls++
+	return components.NewResult("chip", components.Fields{
+		"chip":      sensorChip{Name: "k10temp", Cores: 8},
+		"bytes":     uint64(1<<62 + 1),
+		"ratio":     0.5,
+		"flags":     []string{"a", "b"},
+		"per_core":  map[string]int{"0": 40, "1": 42},
+		"reading_c": s.reading,
+	})
+}
+
+func (s *sensorInfo) CacheKey() (string, bool) { return "boot-1", true }
+
+func (s *sensorInfo) LiveFields() components.Fields {
+	return components.Fields{"reading_c": s.reading}
+}
+
+func sensorManager(t *testing.T, dir string, sensor *sensorInfo) *DisplayManager {
+	t.Helper()
+	config := DefaultConfig()
+	config.Layout = []string{"cpu"}
+	config.Cache.TTL = map[string]int{"cpu": 3600}
+	d := NewDisplayManager(config)
+	d.diskCache = common.NewDiskCache(dir)
+	d.InfoProviders["cpu"] = sensor
+	return d
+}
+
+// render formats the collected modules without their collection times,
+// which differ from run to run
+func render(t *testing.T, d *DisplayManager, format string) string {
+	t.Helper()
+	modules := d.Collect()
+	for i := range modules {
+		modules[i].CollectionTimeMs = 0
+	}
+	output, err := renderModules(format, modules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+func TestModuleCacheGivesFreshOutput(t *testing.T) {
+	dir := t.TempDir()
+	sensor := &sensorInfo{reading: 41.5}
+
+	for _, format := range []string{OutputFormatJSON, OutputFormatYAML, OutputFormatTOML} {
+		sensor.calls = 0
+		fresh := render(t, sensorManager(t, t.TempDir(), sensor), format)
+		if sensor.calls != 1 {
+			t.Fatalf("%s: GetInfo called %d times", format, sensor.calls)
+		}
+
+		// The first run fills the cache, the second reads from it
+		render(t, sensorManager(t, dir, sensor), format)
+		calls := sensor.calls
+		cached := render(t, sensorManager(t, dir, sensor), format)
+		if sensor.calls != calls {
+			t.Fatalf("%s: cached run called GetInfo", format)
+		}
+		if cached != fresh {
+			t.Errorf("%s: cached output\n%s\nfresh output\n%s", format, cached, fresh)
+		}
+	}
+}
+
+func TestModuleCacheRereadsLiveFields(t *testing.T) {
+	dir := t.TempDir()
+	sensor := &sensorInfo{reading: 41.5}
+	sensorManager(t, dir, sensor).Collect()
+
+	sensor.reading = 63
+	modules := sensorManager(t, dir, sensor).Collect()
+	if sensor.calls != 1 {
+		t.Fatalf("GetInfo called %d times", sensor.calls)
+	}
+	if got := fmt.Sprint(modules[0].Fields["reading_c"]); got != "63" {
+		t.Errorf("reading_c = %v, want the live 63", got)
+	}
+}
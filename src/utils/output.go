@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"lunarfetch/src/components"
+)
+
+const (
+	OutputFormatJSON = "json"
+	OutputFormatYAML = "yaml"
+	OutputFormatTOML = "toml"
+)
+
+type ModuleOutput struct {
+	Key              string            `json:"key"`
+	Label            string            `json:"label"`
+	Value            string            `json:"value"`
+	Lines            []components.Line `json:"lines,omitempty"`
+	Fields           components.Fields `json:"fields,omitempty"`
+	Error            string            `json:"error,omitempty"`
+	CollectionTimeMs float64           `json:"collection_time_ms"`
+}
+
+func IsOutputFormat(format string) bool {
+	switch format {
+	case OutputFormatJSON, OutputFormatYAML, OutputFormatTOML:
+		return true
+	}
+	return false
+}
+
+func (d *DisplayManager) Collect() []ModuleOutput {
+	d.GetInfoParallel()
+
+	d.cacheMutex.RLock()
+	defer d.cacheMutex.RUnlock()
+
+	var modules []ModuleOutput
+	for _, key := range d.enabledModules() {
+		reg, _ := components.Lookup(key)
+		result := d.infoCache[key]
+
+		// Typed fields are made generic as cached ones are, so a fresh and a
+		// cached result give the same output
+		fields := result.Fields
+		if generic, err := toGeneric(fields); err == nil {
+			if m, ok := generic.(map[string]interface{}); ok {
+				fields = m
+			}
+		}
+
+		module := ModuleOutput{
+			Key:              key,
+			Label:            reg.Label,
+			Value:            result.Value,
+			Lines:            result.Lines,
+			Fields:           fields,
+			CollectionTimeMs: math.Round(float64(d.infoTimes[key])/float64(time.Microsecond)) / 1000,
+		}
+		if result.Err != nil {
+			module.Error = result.Err.Error()
+		}
+		modules = append(modules, module)
+	}
+
+	return modules
+}
+
+func (d *DisplayManager) Render(format string) (string, error) {
+	return renderModules(format, d.Collect())
+}
+
+// renderModules writes collected modules in one of the output formats
+func renderModules(format string, modules []ModuleOutput) (string, error) {
+	switch format {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(struct {
+			Modules []ModuleOutput `json:"modules"`
+		}{modules}, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	case OutputFormatYAML, OutputFormatTOML:
+		documents := make([]moduleDocument, 0, len(modules))
+		for _, module := range modules {
+			document, err := module.document(format == OutputFormatTOML)
+			if err != nil {
+				return "", err
+			}
+			documents = append(documents, document)
+		}
+
+		var out bytes.Buffer
+		if format == OutputFormatYAML {
+			encoder := yaml.NewEncoder(&out)
+			encoder.SetIndent(2)
+			if err := encoder.Encode(moduleDocuments{documents}); err != nil {
+				return "", err
+			}
+			if err := encoder.Close(); err != nil {
+				return "", err
+			}
+		} else {
+			encoder := toml.NewEncoder(&out)
+			encoder.Indent = ""
+			if err := encoder.Encode(moduleDocuments{documents}); err != nil {
+				return "", err
+			}
+		}
+		return out.String(), nil
+	}
+
+	return "", fmt.Errorf("unsupported output format: %s", format)
+}
+
+type moduleDocuments struct {
+	Modules []moduleDocument `yaml:"modules" toml:"modules"`
+}
+
+// moduleDocument is a module as the YAML and TOML encoders see it: the keys
+// of the JSON output in the same order, with lines and fields made generic
+type moduleDocument struct {
+	Key              string      `yaml:"key" toml:"key"`
+	Label            string      `yaml:"label" toml:"label"`
+	Value            string      `yaml:"value" toml:"value"`
+	Lines            interface{} `yaml:"lines,omitempty" toml:"lines,omitempty"`
+	Fields           interface{} `yaml:"fields,omitempty" toml:"fields,omitempty"`
+	Error            string      `yaml:"error,omitempty" toml:"error,omitempty"`
+	CollectionTimeMs float64     `yaml:"collection_time_ms" toml:"collection_time_ms"`
+}
+
+// document converts the module for the YAML or TOML encoder. TOML has no
+// null, so dropNulls leaves null fields and list items out.
+func (m ModuleOutput) document(dropNulls bool) (moduleDocument, error) {
+	// Invalid UTF-8 becomes U+FFFD as in JSON; YAML would encode it as binary
+	document := moduleDocument{
+		Key:              strings.ToValidUTF8(m.Key, "\uFFFD"),
+		Label:            strings.ToValidUTF8(m.Label, "\uFFFD"),
+		Value:            strings.ToValidUTF8(m.Value, "\uFFFD"),
+		Error:            strings.ToValidUTF8(m.Error, "\uFFFD"),
+		CollectionTimeMs: m.CollectionTimeMs,
+	}
+
+	if len(m.Lines) > 0 {
+		lines, err := toGeneric(m.Lines)
+		if err != nil {
+			return document, err
+		}
+		document.Lines = lines
+	}
+	if len(m.Fields) > 0 {
+		fields, err := toGeneric(m.Fields)
+		if err != nil {
+			return document, err
+		}
+		if dropNulls {
+			fields = withoutNulls(fields)
+		}
+		document.Fields = fields
+	}
+	return document, nil
+}
+
+// toGeneric normalises typed component fields into the maps, slices, strings,
+// numbers and booleans they are in the JSON output, so YAML and TOML show the
+// same keys. Whole numbers stay integers.
+func toGeneric(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGeneric(data)
+}
+
+// decodeGeneric decodes JSON the way toGeneric leaves values
+func decodeGeneric(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return plainNumbers(generic), nil
+}
+
+// plainNumbers replaces json.Number values with int64 or float64
+func plainNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = plainNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	}
+	return value
+}
+
+// withoutNulls drops null map values and list items
+func withoutNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if item != nil {
+				m[key] = withoutNulls(item)
+			}
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				list = append(list, withoutNulls(item))
+			}
+		}
+		return list
+	}
+	return value
+}
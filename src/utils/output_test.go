@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"lunarfetch/src/components"
+)
+
+func sampleModules() []ModuleOutput {
+	return []ModuleOutput{
+		{
+			Key:   "cpu",
+			Label: "CPU",
+			Value: "AMD Ryzen 7: 8 cores # boost",
+			Fields: components.Fields{
+				"cores": 8,
+				"model": `say "hi" \ bye`,
+				"flags": []string{"sse", "avx2"},
+				"cache": map[string]interface{}{"l2": "8 MB", "l3": nil, "levels": map[string]int{"count": 3}},
+			},
+			CollectionTimeMs: 1.25,
+		},
+		{
+			Key:   "disk",
+			Label: "Disk",
+			Value: "",
+			Lines: []components.Line{
+				{Label: "Disk (/)", Value: "1 / 2\nsecond line"},
+				{Label: "Disk (/mnt/Café 日本)", Value: "ok ✓ 🚀"},
+			},
+			Fields: components.Fields{
+				"mounts": []map[string]interface{}{
+					{"mountpoint": "/", "percent": 40.5},
+					{"mountpoint": "nas:/export", "percent": 0},
+				},
+				"empty":        map[string]string{},
+				"none":         []string{},
+				"matrix":       [][]int{{1, 2}, {}},
+				"key: #1":      "tab\there\x1b[0m",
+				"a.b":          true,
+				"true":         "false",
+				"0":            "",
+				"日本":           "null",
+				"nothing":      nil,
+				"mixed":        []interface{}{"x", nil, 2},
+				"indent thing": " leading and trailing ",
+			},
+			Error:            `exit status 1: "boom"`,
+			CollectionTimeMs: 0,
+		},
+		// Invalid UTF-8 reads back as U+FFFD, as it does in JSON
+		{Key: "host", Value: "true", Fields: components.Fields{"name": "bad\xffbyte"}, Error: "bad\xfe"},
+	}
+}
+
+// expectedDocument is what the sample modules read back as: their JSON,
+// without the nulls TOML has no way to write when dropNulls is set
+func expectedDocument(t *testing.T, dropNulls bool) interface{} {
+	t.Helper()
+	data, err := json.Marshal(struct {
+		Modules []ModuleOutput `json:"modules"`
+	}{sampleModules()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	if dropNulls {
+		document = withoutNulls(document)
+	}
+	return document
+}
+
+// asFloats turns the integers decoders read into float64 and arrays of
+// tables into plain lists, as in decoded JSON
+func asFloats(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = asFloats(item)
+		}
+		return list
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = asFloats(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = asFloats(item)
+		}
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return value
+}
+
+func TestRenderYAMLRoundTrip(t *testing.T) {
+	output, err := renderModules(OutputFormatYAML, sampleModules())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got interface{}
+	if err := yaml.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("%v in\n%s", err, output)
+	}
+	if want := expectedDocument(t, false); !reflect.DeepEqual(asFloats(got), want) {
+		t.Errorf("read back\n%v\nwant\n%v\nfrom\n%s", got, want, output)
+	}
+}
+
+func TestRenderTOMLRoundTrip(t *testing.T) {
+	output, err := renderModules(OutputFormatTOML, sampleModules())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if _, err := toml.Decode(output, &got); err != nil {
+		t.Fatalf("%v in\n%s", err, output)
+	}
+	if want := expectedDocument(t, true); !reflect.DeepEqual(asFloats(got), want) {
+		t.Errorf("read back\n%v\nwant\n%v\nfrom\n%s", got, want, output)
+	}
+}
+
+func TestRenderNoModules(t *testing.T) {
+	if output, _ := renderModules(OutputFormatYAML, nil); output != "modules: []\n" {
+		t.Errorf("yaml = %q", output)
+	}
+	if output, _ := renderModules(OutputFormatTOML, nil); output != "modules = []\n" {
+		t.Errorf("toml = %q", output)
+	}
+	if _, err := renderModules("xml", nil); err == nil {
+		t.Error("xml: no error")
+	}
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// TerminalSize returns the number of columns and rows of the terminal.
+// COLUMNS and LINES win when set, so the size can be forced, e.g. in
+// scripts; otherwise stdout is asked. Zero means the size is unknown, as
+// when the output is piped.
+func TerminalSize() (cols, rows int) {
+	cols, rows = windowSize(os.Stdout)
+	if env, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && env > 0 {
+		cols = env
+	}
+	if env, err := strconv.Atoi(os.Getenv("LINES")); err == nil && env > 0 {
+		rows = env
+	}
+	return cols, rows
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package utils
+
+import "os"
+
+// isTerminal tells whether f is a character device, as terminals are
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// windowSize can't ask the terminal on this platform; COLUMNS and LINES
+// still work
+func windowSize(f *os.File) (cols, rows int) {
+	return 0, 0
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package utils
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// getWinsize asks the terminal behind f for its size with TIOCGWINSZ; it
+// fails when f is not a terminal
+func getWinsize(f *os.File) (winsize, bool) {
+	var size winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	return size, errno == 0
+}
+
+// isTerminal tells whether f is a terminal
+func isTerminal(f *os.File) bool {
+	_, ok := getWinsize(f)
+	return ok
+}
+
+// windowSize returns the size of the terminal behind f, or zero
+func windowSize(f *os.File) (cols, rows int) {
+	size, ok := getWinsize(f)
+	if !ok {
+		return 0, 0
+	}
+	return int(size.cols), int(size.rows)
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Escape sequences that take no room on screen: CSI (colors, cursor
+// movement), OSC (titles, hyperlinks, iTerm2 images) and the DCS/APC
+// strings sixel and kitty images are sent in
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9:;<=>?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[P_^X][^\x1b]*\x1b\\`)
+
+// StripANSI removes color and other escape sequences, so text can be
+// measured as it appears on screen
+func StripANSI(text string) string {
+	if !strings.Contains(text, "\x1b") {
+		return text
+	}
+	return ansiPattern.ReplaceAllString(text, "")
+}
+
+// wideRanges are the East Asian Wide and Fullwidth code points, and the
+// emoji terminals draw in two cells
+var wideRanges = []struct{ first, last rune }{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x23E9, 0x23EC},
+	{0x23F0, 0x23F0},
+	{0x23F3, 0x23F3},
+	{0x25FD, 0x25FE},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267F, 0x267F},
+	{0x2693, 0x2693},
+	{0x26A1, 0x26A1},
+	{0x26AA, 0x26AB},
+	{0x26BD, 0x26BE},
+	{0x26C4, 0x26C5},
+	{0x26CE, 0x26CE},
+	{0x26D4, 0x26D4},
+	{0x26EA, 0x26EA},
+	{0x26F2, 0x26F3},
+	{0x26F5, 0x26F5},
+	{0x26FA, 0x26FA},
+	{0x26FD, 0x26FD},
+	{0x2705, 0x2705},
+	{0x270A, 0x270B},
+	{0x2728, 0x2728},
+	{0x274C, 0x274C},
+	{0x274E, 0x274E},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27B0, 0x27B0},
+	{0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50},
+	{0x2B55, 0x2B55},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF},
+	{0x1B000, 0x1B2FF},
+	{0x1F004, 0x1F004},
+	{0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E},
+	{0x1F191, 0x1F19A},
+	{0x1F200, 0x1F251},
+	{0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF},
+	{0x1F7E0, 0x1F7EB},
+	{0x1F900, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal cells r takes. Nerd Font icons
+// live in the Private Use Area and take one cell, like other characters of
+// ambiguous width.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F):
+		// NUL, the zero width joiner and variation selectors
+		return 0
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x1100:
+		if unicode.In(r, unicode.Mn, unicode.Me) {
+			return 0
+		}
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	}
+
+	low, high := 0, len(wideRanges)-1
+	for low <= high {
+		mid := (low + high) / 2
+		switch {
+		case r < wideRanges[mid].first:
+			high = mid - 1
+		case r > wideRanges[mid].last:
+			low = mid + 1
+		default:
+			return 2
+		}
+	}
+	return 1
+}
+
+// DisplayWidth returns the number of terminal cells text takes on one line,
+// ignoring escape sequences
+func DisplayWidth(text string) int {
+	width := 0
+	for _, r := range StripANSI(text) {
+		width += RuneWidth(r)
+	}
+	return width
+}
+
+// PadRight pads text with spaces to width cells
+func PadRight(text string, width int) string {
+	if padding := width - DisplayWidth(text); padding > 0 {
+		return text + strings.Repeat(" ", padding)
+	}
+	return text
+}
+
+// MaxWidth returns the width of the widest line
+func MaxWidth(lines []string) int {
+	widest := 0
+	for _, line := range lines {
+		if width := DisplayWidth(line); width > widest {
+			widest = width
+		}
+	}
+	return widest
+}
+
+// Truncate shortens text to at most width cells, ending it with tail when
+// anything was cut. Escape sequences are kept, and colors are reset after
+// the tail.
+func Truncate(text string, width int, tail string) string {
+	if DisplayWidth(text) <= width {
+		return text
+	}
+	limit := max(width-DisplayWidth(tail), 0)
+
+	var result strings.Builder
+	escaped := false
+	used := 0
+	for rest := text; rest != ""; {
+		if rest[0] == '\x1b' {
+			if loc := ansiPattern.FindStringIndex(rest); loc != nil && loc[0] == 0 {
+				result.WriteString(rest[:loc[1]])
+				rest = rest[loc[1]:]
+				escaped = true
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(rest)
+		if used+RuneWidth(r) > limit {
+			break
+		}
+		used += RuneWidth(r)
+		result.WriteString(rest[:size])
+		rest = rest[size:]
+	}
+
+	result.WriteString(tail)
+	if escaped {
+		result.WriteString(ansiReset)
+	}
+	return result.String()
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// golden compares got with testdata/golden/<name>.golden
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+// rounded draws boxes like the default config
+var rounded = BoxConfig{
+	TopLeft: "╭", TopRight: "╮", BottomLeft: "╰", BottomRight: "╯",
+	TopEdge: "─", BottomEdge: "─", LeftEdge: "│", RightEdge: "│",
+}
+
+// mixedContent has Nerd Font icons, colors, CJK, emoji and combining marks
+func mixedContent(t *testing.T) string {
+	t.Helper()
+	label, err := ParseColor("bold #cba6f7", ColorsTrue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _ := ParseColor("green", Colors16)
+
+	lines := []string{
+		" 󰣇 " + label.Apply("OS") + ": " + value.Apply("Arch Linux x86_64"),
+		" 󰌽 " + label.Apply("Host") + ": ノートパソコン",
+		" 󰀄 " + label.Apply("User") + ": 김민준",
+		" 󰍛 CPU: Ｉｎｔｅｌ Core i7 🔥",
+		" 󰇄 DE: Café Ｘfce",
+		" 󰏗 WM: e\u0301e\u0301 ❤\ufe0f",
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRuneWidth(t *testing.T) {
+	tests := map[rune]int{
+		'a':      1,
+		'é':      1,
+		'\u0301': 0, // combining acute accent
+		'\u200d': 0,
+		'\ufe0f': 0,
+		'\t':     0,
+		'󰣇':      1, // Nerd Font icon in the Private Use Area
+		'\uf303': 1,
+		'│':      1,
+		'日':      2,
+		'ノ':      2,
+		'한':      2,
+		'Ｘ':      2,
+		'🔥':      2,
+		'⚡':      2,
+		'❤':      1,
+	}
+	for r, want := range tests {
+		if got := RuneWidth(r); got != want {
+			t.Errorf("RuneWidth(%U) = %d, want %d", r, got, want)
+		}
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := map[string]int{
+		"":                                  0,
+		"plain":                             5,
+		"\033[1;38;2;1;2;3mOS\033[0m: Arch": 8,
+		"日本語":                               6,
+		"👩‍💻":                               4,
+		"\033_Ga=T,f=100;AAAA\033\\":        0,
+		"\033Pq#0;2;0;0;0~~\033\\":          0,
+		"\033]1337;File=inline=1:AAAA\a":    0,
+	}
+	for text, want := range tests {
+		if got := DisplayWidth(text); got != want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	if got := PadRight("日本", 6); got != "日本  " {
+		t.Errorf("PadRight = %q", got)
+	}
+	if got := PadRight("too wide", 3); got != "too wide" {
+		t.Errorf("PadRight of a wide line = %q", got)
+	}
+}
+
+func TestBoxGolden(t *testing.T) {
+	box := NewBoxDrawer(rounded).Draw(mixedContent(t))
+	for _, line := range strings.Split(strings.TrimRight(box, "\n"), "\n") {
+		if width := DisplayWidth(line); width != DisplayWidth(strings.SplitN(box, "\n", 2)[0]) {
+			t.Errorf("line %q is %d cells wide", StripANSI(line), width)
+		}
+	}
+	golden(t, "box-mixed", box)
+}
+
+// sideBySide renders two panels the way the logo is put next to the box
+func sideBySide(left, right string) string {
+	layout := Layout{}
+	layout.Add(Panel{Content: left, Region: RegionLeft})
+	layout.Add(Panel{Content: right, Region: RegionCenter})
+	return layout.Render()
+}
+
+func TestMergeGolden(t *testing.T) {
+	red, _ := ParseColor("red", Colors16)
+	logo := strings.Join([]string{
+		red.Apply("   /\\   "),
+		red.Apply("  /  \\  "),
+		"  月  ",
+		red.Apply(" /____\\ "),
+	}, "\n")
+	box := NewBoxDrawer(rounded).Draw(mixedContent(t))
+
+	golden(t, "merge-logo-left", sideBySide(logo, box))
+	golden(t, "merge-logo-right", sideBySide(box, logo))
+}
+
+func TestTruncate(t *testing.T) {
+	red, _ := ParseColor("red", Colors16)
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a long value", 6, "a lon…"},
+		{"日本語テキスト", 7, "日本語…"},
+		{red.Apply("colored text"), 5, "\033[31mcolo…\033[0m"},
+		{"éééé", 3, "éé…"},
+	}
+	for _, test := range tests {
+		got := Truncate(test.text, test.width, "…")
+		if got != test.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", test.text, test.width, got, test.want)
+		}
+		if DisplayWidth(got) > test.width {
+			t.Errorf("Truncate(%q, %d) is %d cells wide", test.text, test.width, DisplayWidth(got))
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed themes/*.json
+var builtinThemes embed.FS
+
+const themeExt = ".json"
+
+// ThemeDir holds user themes; a file there replaces the built-in theme of
+// the same name
+var ThemeDir = DefaultThemeDir
+
+// Theme is a named color configuration
+type Theme struct {
+	Name string
+	// Path is the user theme file, empty for built-in themes
+	Path   string
+	Colors ColorConfig
+}
+
+func parseTheme(name, path string, data []byte) (Theme, error) {
+	theme := Theme{Name: name, Path: path}
+	if err := json.Unmarshal(data, &theme.Colors); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", name, err)
+	}
+	return theme, nil
+}
+
+func userThemeDir() string {
+	dir, err := expandPath(ThemeDir)
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// LoadTheme finds a theme by name, looking at user themes first
+func LoadTheme(name string) (Theme, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return Theme{}, fmt.Errorf("invalid theme name %q", name)
+	}
+
+	if dir := userThemeDir(); dir != "" {
+		path := filepath.Join(dir, name+themeExt)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return parseTheme(name, path, data)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return Theme{}, err
+		}
+	}
+
+	data, err := builtinThemes.ReadFile("themes/" + name + themeExt)
+	if err != nil {
+		return Theme{}, fmt.Errorf("unknown theme %q (see lunarfetch themes list)", name)
+	}
+	return parseTheme(name, "", data)
+}
+
+// Themes lists the built-in and user themes by name. Themes that fail to
+// parse are returned as errors rather than hiding the rest.
+func Themes() ([]Theme, []error) {
+	byName := make(map[string]Theme)
+	var errs []error
+
+	entries, _ := builtinThemes.ReadDir("themes")
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), themeExt)
+		data, err := builtinThemes.ReadFile("themes/" + entry.Name())
+		if err == nil {
+			var theme Theme
+			if theme, err = parseTheme(name, "", data); err == nil {
+				byName[name] = theme
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if dir := userThemeDir(); dir != "" {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*"+themeExt))
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), themeExt)
+			data, err := os.ReadFile(path)
+			if err == nil {
+				var theme Theme
+				if theme, err = parseTheme(name, path, data); err == nil {
+					byName[name] = theme
+				}
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	themes := make([]Theme, 0, len(byName))
+	for _, theme := range byName {
+		themes = append(themes, theme)
+	}
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].Name < themes[j].Name
+	})
+	return themes, errs
+}
+
+func mergeScheme(base, override ColorScheme) ColorScheme {
+	if override.Border != "" {
+		base.Border = override.Border
+	}
+	if override.Icon != "" {
+		base.Icon = override.Icon
+	}
+	if override.Label != "" {
+		base.Label = override.Label
+	}
+	if override.Separator != "" {
+		base.Separator = override.Separator
+	}
+	if override.Value != "" {
+		base.Value = override.Value
+	}
+	return base
+}
+
+// mergeColors returns base with every color set in override replacing it
+func mergeColors(base, override ColorConfig) ColorConfig {
+	merged := ColorConfig{
+		ColorScheme: mergeScheme(base.ColorScheme, override.ColorScheme),
+		Depth:       base.Depth,
+		Modules:     make(map[string]ColorScheme),
+	}
+	if override.Depth != "" {
+		merged.Depth = override.Depth
+	}
+	for module, scheme := range base.Modules {
+		merged.Modules[module] = scheme
+	}
+	for module, scheme := range override.Modules {
+		merged.Modules[module] = mergeScheme(merged.Modules[module], scheme)
+	}
+	return merged
+}
+
+// ApplyTheme puts the named theme under the configured colors, so the
+// colors section of config.json can still adjust single parts of it
+func ApplyTheme(config Config, name string) (Config, error) {
+	theme, err := LoadTheme(name)
+	if err != nil {
+		return config, err
+	}
+	config.Theme = name
+	config.Colors = mergeColors(theme.Colors, config.Colors)
+	return config, nil
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func useThemeDir(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := ThemeDir
+	ThemeDir = dir
+	t.Cleanup(func() { ThemeDir = previous })
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	useThemeDir(t, nil)
+
+	themes, errs := Themes()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	want := []string{
+		"catppuccin-frappe", "catppuccin-latte", "catppuccin-macchiato", "catppuccin-mocha",
+		"dracula", "gruvbox", "nord", "solarized-dark", "solarized-light", "tokyonight",
+	}
+	if len(themes) != len(want) {
+		t.Fatalf("got %d themes, want %d", len(themes), len(want))
+	}
+	for i, theme := range themes {
+		if theme.Name != want[i] || theme.Path != "" {
+			t.Errorf("theme %d = %s (%s), want built-in %s", i, theme.Name, theme.Path, want[i])
+		}
+		// Every part is set and every spec is valid
+		if _, errs := NewPalette(theme.Colors); len(errs) != 0 {
+			t.Errorf("%s: %v", theme.Name, errs)
+		}
+		for _, part := range colorParts {
+			if theme.Colors.spec(part) == "" {
+				t.Errorf("%s has no %s color", theme.Name, part)
+			}
+		}
+	}
+}
+
+func TestUserThemes(t *testing.T) {
+	useThemeDir(t, map[string]string{
+		"nord.json":   `{"value": "white"}`,
+		"mine.json":   `{"label": "208"}`,
+		"broken.json": `{"label": `,
+		"notes.txt":   "not a theme",
+	})
+
+	theme, err := LoadTheme("nord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Path == "" || theme.Colors.Value != "white" || theme.Colors.Label != "" {
+		t.Errorf("user nord theme = %+v", theme)
+	}
+
+	themes, errs := Themes()
+	if len(errs) != 1 {
+		t.Errorf("errs = %v, want the broken theme", errs)
+	}
+	found := false
+	for _, theme := range themes {
+		found = found || theme.Name == "mine"
+	}
+	if !found || len(themes) != 11 {
+		t.Errorf("got %d themes, mine found: %v", len(themes), found)
+	}
+
+	if _, err := LoadTheme("missing"); err == nil {
+		t.Error("LoadTheme of an unknown theme succeeded")
+	}
+	if _, err := LoadTheme("../config"); err == nil {
+		t.Error("LoadTheme accepted a path")
+	}
+}
+
+func TestApplyThemeKeepsConfiguredColors(t *testing.T) {
+	useThemeDir(t, nil)
+
+	var config Config
+	config.Colors.Value = "white"
+	config.Colors.Modules = map[string]ColorScheme{"title": {Separator: "red"}}
+
+	config, err := ApplyTheme(config, "dracula")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Theme != "dracula" || config.Colors.Border != "#6272a4" || config.Colors.Value != "white" {
+		t.Errorf("colors = %+v", config.Colors)
+	}
+	if title := config.Colors.Modules["title"]; title.Label != "bold #50fa7b" || title.Separator != "red" {
+		t.Errorf("title = %+v", title)
+	}
+}
@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"lunarfetch/src/common"
+)
+
+const (
+	TimingsText = "text"
+	TimingsJSON = "json"
+)
+
+// Module cache states reported by --timings
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// IsTimingsFormat reports whether format can be passed to --timings
+func IsTimingsFormat(format string) bool {
+	return format == TimingsText || format == TimingsJSON
+}
+
+type ModuleTiming struct {
+	Module   string
+	Duration time.Duration
+	// Cache is CacheHit or CacheMiss when the module's result is cached on
+	// disk, and empty otherwise
+	Cache    string
+	TimedOut bool
+}
+
+type CommandTiming struct {
+	// Module is the module the command ran for; empty for commands run by
+	// the image and logo loaders
+	Module   string
+	Command  string
+	Duration time.Duration
+	ExitCode int
+	Cached   bool
+	Err      error
+}
+
+type PhaseTiming struct {
+	Phase    string
+	Duration time.Duration
+}
+
+// Timings records where a run spends its time. A nil *Timings records
+// nothing, so callers don't need to check whether --timings was given.
+type Timings struct {
+	start    time.Time
+	total    time.Duration
+	modules  map[string]ModuleTiming
+	commands []CommandTiming
+	phases   []PhaseTiming
+	mutex    sync.Mutex
+}
+
+// NewTimings measures the total run time from start
+func NewTimings(start time.Time) *Timings {
+	return &Timings{
+		start:   start,
+		modules: make(map[string]ModuleTiming),
+	}
+}
+
+// RecordModule records how long a module took to collect
+func (t *Timings) RecordModule(module string, duration time.Duration, timedOut bool) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timing := t.modules[module]
+	timing.Module = module
+	timing.Duration = duration
+	timing.TimedOut = timedOut
+	t.modules[module] = timing
+}
+
+// RecordCache records whether a module's result came from the disk cache
+func (t *Timings) RecordCache(module string, hit bool) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timing := t.modules[module]
+	timing.Module = module
+	timing.Cache = CacheMiss
+	if hit {
+		timing.Cache = CacheHit
+	}
+	t.modules[module] = timing
+}
+
+// RecordCommand is a CommandExecutor hook recording every command run
+func (t *Timings) RecordCommand(event common.CommandEvent) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.commands = append(t.commands, CommandTiming{
+		Module:   event.Source,
+		Command:  strings.TrimSpace(event.Name + " " + strings.Join(event.Args, " ")),
+		Duration: event.Duration,
+		ExitCode: event.ExitCode,
+		Cached:   event.Cached,
+		Err:      event.Err,
+	})
+}
+
+// RecordPhase records a step outside the modules, such as decoding the image
+func (t *Timings) RecordPhase(phase string, duration time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.phases = append(t.phases, PhaseTiming{Phase: phase, Duration: duration})
+}
+
+// Finish stops the total run time
+func (t *Timings) Finish() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.total = time.Since(t.start)
+}
+
+// Modules returns the module timings, slowest first
+func (t *Timings) Modules() []ModuleTiming {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	modules := make([]ModuleTiming, 0, len(t.modules))
+	for _, timing := range t.modules {
+		modules = append(modules, timing)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].Duration != modules[j].Duration {
+			return modules[i].Duration > modules[j].Duration
+		}
+		return modules[i].Module < modules[j].Module
+	})
+	return modules
+}
+
+// Write prints the report in the given format, TimingsText or TimingsJSON
+func (t *Timings) Write(w io.Writer, format string) error {
+	if format == TimingsJSON {
+		return t.writeJSON(w)
+	}
+	return t.writeText(w)
+}
+
+func (t *Timings) cacheCounts() (hits, misses int) {
+	for _, module := range t.Modules() {
+		switch module.Cache {
+		case CacheHit:
+			hits++
+		case CacheMiss:
+			misses++
+		}
+	}
+	return hits, misses
+}
+
+func (t *Timings) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Total\t%s\n", formatDuration(t.total))
+	hits, misses := t.cacheCounts()
+	fmt.Fprintf(tw, "Module cache\t%d hits, %d misses\n", hits, misses)
+
+	fmt.Fprintf(tw, "\nModule\tTime\tCache\n")
+	for _, module := range t.Modules() {
+		cache := module.Cache
+		if module.TimedOut {
+			cache = "timeout"
+		} else if cache == "" {
+			cache = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", module.Module, formatDuration(module.Duration), cache)
+	}
+
+	t.mutex.Lock()
+	commands := t.commands
+	phases := t.phases
+	t.mutex.Unlock()
+
+	if len(commands) > 0 {
+		fmt.Fprintf(tw, "\nCommand\tTime\tModule\tResult\n")
+		for _, command := range commands {
+			module := command.Module
+			if module == "" {
+				module = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", command.Command, formatDuration(command.Duration), module, commandResult(command))
+		}
+	}
+
+	if len(phases) > 0 {
+		fmt.Fprintf(tw, "\nPhase\tTime\n")
+		for _, phase := range phases {
+			fmt.Fprintf(tw, "%s\t%s\n", phase.Phase, formatDuration(phase.Duration))
+		}
+	}
+
+	return tw.Flush()
+}
+
+func commandResult(command CommandTiming) string {
+	switch {
+	case command.Cached:
+		return "cached"
+	case command.ExitCode == -1 && command.Err != nil:
+		return command.Err.Error()
+	default:
+		return fmt.Sprintf("exit %d", command.ExitCode)
+	}
+}
+
+func formatDuration(duration time.Duration) string {
+	return fmt.Sprintf("%.2fms", milliseconds(duration))
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// The JSON report gives durations in milliseconds so it can be compared
+// across runs without parsing Go duration strings
+type timingsReport struct {
+	TotalMs     float64         `json:"total_ms"`
+	CacheHits   int             `json:"cache_hits"`
+	CacheMisses int             `json:"cache_misses"`
+	Modules     []moduleReport  `json:"modules"`
+	Commands    []commandReport `json:"commands"`
+	Phases      []phaseReport   `json:"phases"`
+}
+
+type moduleReport struct {
+	Module     string  `json:"module"`
+	DurationMs float64 `json:"duration_ms"`
+	Cache      string  `json:"cache,omitempty"`
+	TimedOut   bool    `json:"timed_out,omitempty"`
+}
+
+type commandReport struct {
+	Command    string  `json:"command"`
+	Module     string  `json:"module,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+	ExitCode   int     `json:"exit_code"`
+	Cached     bool    `json:"cached,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type phaseReport struct {
+	Phase      string  `json:"phase"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+func (t *Timings) writeJSON(w io.Writer) error {
+	report := timingsReport{
+		TotalMs:  milliseconds(t.total),
+		Modules:  []moduleReport{},
+		Commands: []commandReport{},
+		Phases:   []phaseReport{},
+	}
+	report.CacheHits, report.CacheMisses = t.cacheCounts()
+
+	for _, module := range t.Modules() {
+		report.Modules = append(report.Modules, moduleReport{
+			Module:     module.Module,
+			DurationMs: milliseconds(module.Duration),
+			Cache:      module.Cache,
+			TimedOut:   module.TimedOut,
+		})
+	}
+
+	t.mutex.Lock()
+	for _, command := range t.commands {
+		entry := commandReport{
+			Command:    command.Command,
+			Module:     command.Module,
+			DurationMs: milliseconds(command.Duration),
+			ExitCode:   command.ExitCode,
+			Cached:     command.Cached,
+		}
+		if command.Err != nil {
+			entry.Error = command.Err.Error()
+		}
+		report.Commands = append(report.Commands, entry)
+	}
+	for _, phase := range t.phases {
+		report.Phases = append(report.Phases, phaseReport{Phase: phase.Phase, DurationMs: milliseconds(phase.Duration)})
+	}
+	t.mutex.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
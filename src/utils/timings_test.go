@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lunarfetch/src/common"
+)
+
+func sampleTimings() *Timings {
+	timings := NewTimings(time.Now().Add(-40 * time.Millisecond))
+	timings.RecordModule("kernel", 2*time.Millisecond, false)
+	timings.RecordCache("packages", true)
+	timings.RecordModule("packages", 30*time.Millisecond, false)
+	timings.RecordModule("gpu", 100*time.Millisecond, true)
+	timings.RecordCommand(common.CommandEvent{Name: "uname", Args: []string{"-r"}, Duration: time.Millisecond, Source: "kernel"})
+	timings.RecordCommand(common.CommandEvent{Name: "lspci", Source: "gpu", ExitCode: -1, Err: errors.New("timeout")})
+	timings.RecordPhase("image decode", 5*time.Millisecond)
+	timings.Finish()
+	return timings
+}
+
+func TestTimingsModulesSlowestFirst(t *testing.T) {
+	var order []string
+	for _, module := range sampleTimings().Modules() {
+		order = append(order, module.Module)
+	}
+	if strings.Join(order, ",") != "gpu,packages,kernel" {
+		t.Errorf("order = %v", order)
+	}
+}
+
+func TestTimingsText(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTimings().Write(&out, TimingsText); err != nil {
+		t.Fatal(err)
+	}
+	report := out.String()
+	for _, want := range []string{
+		"Module cache  1 hits, 0 misses",
+		"gpu       100.00ms  timeout",
+		"packages  30.00ms   hit",
+		"uname -r  1.00ms  kernel  exit 0",
+		"lspci     0.00ms  gpu     timeout",
+		"image decode  5.00ms",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestTimingsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := sampleTimings().Write(&out, TimingsJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var report timingsReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.TotalMs < 40 || report.CacheHits != 1 || len(report.Modules) != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Modules[0] != (moduleReport{Module: "gpu", DurationMs: 100, TimedOut: true}) {
+		t.Errorf("slowest module = %+v", report.Modules[0])
+	}
+	if len(report.Commands) != 2 || report.Commands[1].Error != "timeout" {
+		t.Errorf("commands = %+v", report.Commands)
+	}
+
+	// Keys are snake_case like the --format json output
+	for _, key := range []string{`"total_ms"`, `"cache_hits"`, `"cache_misses"`, `"duration_ms"`, `"timed_out"`, `"exit_code"`} {
+		if !bytes.Contains(out.Bytes(), []byte(key)) {
+			t.Errorf("report has no %s key:\n%s", key, out.String())
+		}
+	}
+}
+
+func TestNilTimingsRecordsNothing(t *testing.T) {
+	var timings *Timings
+	timings.RecordModule("kernel", time.Millisecond, false)
+	timings.RecordCache("kernel", true)
+	timings.RecordCommand(common.CommandEvent{Name: "uname"})
+	timings.RecordPhase("config", time.Millisecond)
+	timings.Finish()
+}